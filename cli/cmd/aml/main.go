@@ -1,10 +1,28 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+
 	"github.com/acorn-io/aml/cli/pkg/cmds"
-	"github.com/acorn-io/cmd"
 )
 
 func main() {
-	cmd.Main(cmds.NewRootCommand())
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := cmds.NewRootCommand().ExecuteContext(ctx)
+	stop()
+
+	var exitErr *cmds.ExitCodeError
+	if errors.As(err, &exitErr) {
+		if exitErr.Err != nil {
+			fmt.Fprintln(os.Stderr, exitErr.Err)
+		}
+		os.Exit(exitErr.Code)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 }
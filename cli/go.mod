@@ -3,13 +3,12 @@ module github.com/acorn-io/aml/cli
 go 1.21.0
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/acorn-io/cmd v0.0.0
 	github.com/spf13/cobra v1.7.0
+	github.com/spf13/pflag v1.0.5
 )
 
-require (
-	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
-)
+require github.com/inconshreveable/mousetrap v1.1.0 // indirect
 
 replace github.com/acorn-io/cmd => ../../cmd
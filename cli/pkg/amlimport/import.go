@@ -0,0 +1,223 @@
+package amlimport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/acorn-io/aml"
+	"gopkg.in/yaml.v3"
+)
+
+type Options struct {
+	// Key is a dotted path to a field in each document of a multi-document YAML file. If set the
+	// documents are written as an object keyed by the value of that field instead of as an array.
+	Key string
+	// Format is one of yaml, json, or toml. If empty the format is determined from the file name.
+	Format string
+}
+
+// Convert converts a YAML, JSON, or TOML document to formatted AML
+func Convert(filename string, data []byte, opts Options) ([]byte, error) {
+	format := opts.Format
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	}
+
+	var (
+		node *yaml.Node
+		err  error
+	)
+	switch format {
+	case "yaml", "yml", "json":
+		node, err = yamlToNode(data, opts.Key)
+	case "toml":
+		node, err = tomlToNode(data)
+	default:
+		return nil, fmt.Errorf("unsupported format %q for %s, must be yaml, json, or toml", format, filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filename, err)
+	}
+
+	return aml.Marshal(node)
+}
+
+func yamlToNode(data []byte, key string) (*yaml.Node, error) {
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		doc := &yaml.Node{}
+		if err := dec.Decode(doc); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) > 0 {
+			docs = append(docs, doc)
+		}
+	}
+
+	switch {
+	case len(docs) == 0:
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	case len(docs) == 1:
+		return docs[0], nil
+	case key == "":
+		return documentsToSequence(docs), nil
+	}
+	return documentsToMapping(docs, key)
+}
+
+func documentsToSequence(docs []*yaml.Node) *yaml.Node {
+	result := &yaml.Node{
+		Kind: yaml.SequenceNode,
+		Tag:  "!!seq",
+	}
+	for _, doc := range docs {
+		item := doc.Content[0]
+		item.HeadComment = joinComments(doc.HeadComment, item.HeadComment)
+		item.FootComment = joinComments(item.FootComment, doc.FootComment)
+		result.Content = append(result.Content, item)
+	}
+	return result
+}
+
+func documentsToMapping(docs []*yaml.Node, key string) (*yaml.Node, error) {
+	result := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Tag:  "!!map",
+	}
+	seen := map[string]int{}
+	for _, doc := range docs {
+		value := doc.Content[0]
+		keyValue, ok := lookup(value, strings.Split(key, "."))
+		if !ok {
+			return nil, fmt.Errorf("document on line %d does not have a string value for key %s", value.Line, key)
+		}
+		if line, ok := seen[keyValue]; ok {
+			return nil, fmt.Errorf("documents on line %d and %d have the same value %q for key %s", line, value.Line, keyValue, key)
+		}
+		seen[keyValue] = value.Line
+
+		result.Content = append(result.Content, &yaml.Node{
+			Kind:        yaml.ScalarNode,
+			Tag:         "!!str",
+			Value:       keyValue,
+			HeadComment: joinComments(doc.HeadComment, value.HeadComment),
+		}, value)
+		value.HeadComment = ""
+		value.FootComment = joinComments(value.FootComment, doc.FootComment)
+	}
+	return result, nil
+}
+
+func lookup(node *yaml.Node, path []string) (string, bool) {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if len(path) == 0 {
+		return node.Value, node.Kind == yaml.ScalarNode && node.Value != ""
+	}
+	if node.Kind != yaml.MappingNode {
+		return "", false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == path[0] {
+			return lookup(node.Content[i+1], path[1:])
+		}
+	}
+	return "", false
+}
+
+func joinComments(comments ...string) string {
+	var result []string
+	for _, comment := range comments {
+		if comment != "" {
+			result = append(result, comment)
+		}
+	}
+	return strings.Join(result, "\n")
+}
+
+func tomlToNode(data []byte) (*yaml.Node, error) {
+	values := map[string]any{}
+	md, err := toml.Decode(string(data), &values)
+	if err != nil {
+		return nil, err
+	}
+
+	// TOML keys are decoded into a map so the order of the keys in the file is restored from the metadata
+	order := map[string]int{}
+	for i, key := range md.Keys() {
+		if _, ok := order[key.String()]; !ok {
+			order[key.String()] = i
+		}
+	}
+
+	return tomlValueToNode(order, nil, values)
+}
+
+func tomlValueToNode(order map[string]int, path []string, value any) (*yaml.Node, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			oi, iok := order[toml.Key(append(path, keys[i])).String()]
+			oj, jok := order[toml.Key(append(path, keys[j])).String()]
+			if iok && jok {
+				return oi < oj
+			} else if iok != jok {
+				return iok
+			}
+			return keys[i] < keys[j]
+		})
+
+		result := &yaml.Node{
+			Kind: yaml.MappingNode,
+			Tag:  "!!map",
+		}
+		for _, key := range keys {
+			child, err := tomlValueToNode(order, append(path[:len(path):len(path)], key), v[key])
+			if err != nil {
+				return nil, err
+			}
+			result.Content = append(result.Content, &yaml.Node{
+				Kind:  yaml.ScalarNode,
+				Tag:   "!!str",
+				Value: key,
+			}, child)
+		}
+		return result, nil
+	case []map[string]any:
+		items := make([]any, 0, len(v))
+		for _, item := range v {
+			items = append(items, item)
+		}
+		return tomlValueToNode(order, path, items)
+	case []any:
+		result := &yaml.Node{
+			Kind: yaml.SequenceNode,
+			Tag:  "!!seq",
+		}
+		for _, item := range v {
+			child, err := tomlValueToNode(order, path, item)
+			if err != nil {
+				return nil, err
+			}
+			result.Content = append(result.Content, child)
+		}
+		return result, nil
+	}
+
+	result := &yaml.Node{}
+	return result, result.Encode(value)
+}
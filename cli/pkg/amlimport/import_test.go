@@ -0,0 +1,40 @@
+package amlimport
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	dir := fmt.Sprintf("testdata/%s", t.Name())
+	files, err := os.ReadDir(dir)
+	require.Nil(t, err)
+
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".golden") {
+			continue
+		}
+		t.Run(file.Name(), func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+			require.NoError(t, err)
+
+			var opts Options
+			if strings.HasPrefix(file.Name(), "keyed-") {
+				opts.Key = "metadata.name"
+			}
+
+			out, err := Convert(file.Name(), data, opts)
+			if err != nil {
+				autogold.ExpectFile(t, err)
+			} else {
+				autogold.ExpectFile(t, autogold.Raw(out))
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package amltest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/acorn-io/aml"
+	"github.com/acorn-io/aml/cli/pkg/diff"
+	"github.com/acorn-io/aml/pkg/ast"
+	"github.com/acorn-io/aml/pkg/encrypt"
+	"github.com/acorn-io/aml/pkg/eval"
+	"github.com/acorn-io/aml/pkg/value"
+)
+
+const (
+	// FileSuffix is the suffix of the files that contain tests
+	FileSuffix = "_test.acorn"
+	// TestPrefix is the prefix of the top-level fields that are tests
+	TestPrefix = "test_"
+)
+
+type Options struct {
+	// Run is a regular expression, only the tests with a matching name are run
+	Run string
+	// DecryptionKey is the key used by std.decrypt in the test files
+	DecryptionKey []byte
+	// Coverage records the blocks of the test files evaluated by the tests if set
+	Coverage *eval.Coverage
+}
+
+type Result struct {
+	File string
+	Name string
+	Pos  eval.Position
+	// Failure describes why the test failed, it is empty if the test passed
+	Failure  string
+	Duration time.Duration
+}
+
+func (r Result) Passed() bool {
+	return r.Failure == ""
+}
+
+// Files returns the test files in paths. Directories are searched recursively, skipping
+// directories that start with a dot, files are returned as is.
+func Files(paths []string) (result []string, _ error) {
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			result = append(result, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && p != path && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() && strings.HasSuffix(d.Name(), FileSuffix) {
+				result = append(result, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// Run runs the tests of each file. A test is a top-level field whose name starts with test_ and
+// whose value is either a bool that must be true or an object with expect and actual fields that
+// must be equal. Each test is evaluated on its own, so an error in one test does not fail the
+// other tests of the file.
+func Run(ctx context.Context, files []string, opts Options) ([]Result, error) {
+	var run *regexp.Regexp
+	if opts.Run != "" {
+		var err error
+		run, err = regexp.Compile(opts.Run)
+		if err != nil {
+			return nil, fmt.Errorf("invalid run expression: %w", err)
+		}
+	}
+
+	var result []Result
+	for _, file := range files {
+		results, err := runFile(ctx, file, run, opts)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s: %w", file, err)
+		}
+		result = append(result, results...)
+	}
+	return result, nil
+}
+
+func runFile(ctx context.Context, filename string, run *regexp.Regexp, opts Options) ([]Result, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var file eval.File
+	if err := aml.Unmarshal(data, &file, aml.DecoderOption{
+		SourceName: filename,
+		Context:    ctx,
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(opts.DecryptionKey) > 0 {
+		ctx = encrypt.WithKey(ctx, opts.DecryptionKey)
+	}
+	if opts.Coverage != nil {
+		var parsed ast.File
+		if err := aml.Unmarshal(data, &parsed, aml.DecoderOption{
+			SourceName: filename,
+		}); err != nil {
+			return nil, err
+		}
+		opts.Coverage.AddFile(&parsed)
+		ctx = eval.WithCoverage(ctx, opts.Coverage)
+	}
+	scope := eval.Builtin.Push(nil, eval.ScopeOption{
+		Context: ctx,
+	})
+	fileScope, err := file.Scope(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Result
+	for _, field := range file.Body.Fields {
+		kv, ok := field.(*eval.KeyValue)
+		if !ok || kv.Local || kv.Key.IsMatch() {
+			continue
+		}
+		name, ok, err := kv.Key.ToString(fileScope)
+		if err != nil {
+			return nil, err
+		} else if !ok || !strings.HasPrefix(name, TestPrefix) {
+			continue
+		}
+		if run != nil && !run.MatchString(name) {
+			continue
+		}
+
+		start := time.Now()
+		failure := runTest(&file, scope, name)
+		result = append(result, Result{
+			File:     filename,
+			Name:     name,
+			Pos:      kv.Pos,
+			Failure:  failure,
+			Duration: time.Since(start),
+		})
+	}
+
+	return result, nil
+}
+
+// runTest evaluates a single test and returns the reason it failed or an empty string
+func runTest(file *eval.File, scope eval.Scope, name string) string {
+	val, _, ok, err := file.ToValueForPath(scope, []string{name})
+	if err != nil {
+		return err.Error()
+	} else if !ok || val.Kind() == value.UndefinedKind {
+		return "test did not produce a value"
+	}
+
+	switch val.Kind() {
+	case value.BoolKind:
+		b, err := value.ToBool(val)
+		if err != nil {
+			return err.Error()
+		} else if !b {
+			return "assertion is false"
+		}
+		return ""
+	case value.ObjectKind:
+		return compare(val)
+	}
+	return fmt.Sprintf("test must be a bool or an object with expect and actual fields, got kind %s", val.Kind())
+}
+
+func compare(val value.Value) string {
+	keys, err := value.Keys(val)
+	if err != nil {
+		return err.Error()
+	}
+	if len(keys) != 2 || !contains(keys, "expect") || !contains(keys, "actual") {
+		return fmt.Sprintf("test object must have only the fields expect and actual, got fields %s", strings.Join(keys, ", "))
+	}
+
+	expect, _, err := value.Lookup(val, value.NewValue("expect"))
+	if err != nil {
+		return err.Error()
+	}
+	actual, _, err := value.Lookup(val, value.NewValue("actual"))
+	if err != nil {
+		return err.Error()
+	}
+
+	changes, err := diff.Values(expect, actual, diff.Options{})
+	if err != nil {
+		return err.Error()
+	} else if len(changes) == 0 {
+		return ""
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("actual does not match expect (- expect, + actual)\n")
+	if err := diff.WriteText(buf, changes); err != nil {
+		return err.Error()
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
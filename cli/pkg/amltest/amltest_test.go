@@ -0,0 +1,63 @@
+package amltest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiles(t *testing.T) {
+	files, err := Files([]string{"testdata"})
+	require.NoError(t, err)
+	autogold.Expect([]string{"testdata/lib/math_test.acorn", "testdata/lib/strings_test.acorn"}).Equal(t, files)
+}
+
+func TestRun(t *testing.T) {
+	files, err := Files([]string{"testdata"})
+	require.NoError(t, err)
+
+	results, err := Run(context.Background(), files, Options{})
+	require.NoError(t, err)
+	autogold.Expect(int(4)).Equal(t, Failed(results))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteText(buf, results, true))
+	autogold.ExpectFile(t, autogold.Raw(buf.String()))
+}
+
+func TestRunFilter(t *testing.T) {
+	results, err := Run(context.Background(), []string{"testdata/lib/math_test.acorn"}, Options{
+		Run: "^test_add",
+	})
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteText(buf, results, true))
+	autogold.Expect(`--- PASS: test_add (testdata/lib/math_test.acorn:11:1)
+--- FAIL: test_add_pair (testdata/lib/math_test.acorn:13:1)
+    actual does not match expect (- expect, + actual)
+    ~ sum: 4 -> 3
+FAIL testdata/lib/math_test.acorn 1 of 2 test(s) failed
+`).Equal(t, buf.String())
+
+	_, err = Run(context.Background(), nil, Options{Run: "("})
+	autogold.Expect("invalid run expression: error parsing regexp: missing closing ): `(`").Equal(t, err.Error())
+}
+
+func TestWriteJUnit(t *testing.T) {
+	files, err := Files([]string{"testdata"})
+	require.NoError(t, err)
+
+	results, err := Run(context.Background(), files, Options{})
+	require.NoError(t, err)
+	for i := range results {
+		results[i].Duration = 0
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteJUnit(buf, results))
+	autogold.ExpectFile(t, autogold.Raw(buf.String()))
+}
@@ -0,0 +1,143 @@
+package amltest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Failed returns the number of failed tests
+func Failed(results []Result) (count int) {
+	for _, result := range results {
+		if !result.Passed() {
+			count++
+		}
+	}
+	return
+}
+
+// byFile groups the results by file, keeping the order of the files
+func byFile(results []Result) (files []string, grouped map[string][]Result) {
+	grouped = map[string][]Result{}
+	for _, result := range results {
+		if _, ok := grouped[result.File]; !ok {
+			files = append(files, result.File)
+		}
+		grouped[result.File] = append(grouped[result.File], result)
+	}
+	return
+}
+
+// WriteText writes a line for each failed test followed by the reason it failed and a summary line
+// for each file. If verbose is set passed tests are also listed.
+func WriteText(w io.Writer, results []Result, verbose bool) error {
+	buf := &strings.Builder{}
+	files, grouped := byFile(results)
+	for _, file := range files {
+		for _, result := range grouped[file] {
+			if result.Passed() {
+				if verbose {
+					fmt.Fprintf(buf, "--- PASS: %s (%s)\n", result.Name, result.Pos)
+				}
+				continue
+			}
+			fmt.Fprintf(buf, "--- FAIL: %s (%s)\n", result.Name, result.Pos)
+			for _, line := range strings.Split(result.Failure, "\n") {
+				fmt.Fprintf(buf, "    %s\n", line)
+			}
+		}
+		if failed := Failed(grouped[file]); failed > 0 {
+			fmt.Fprintf(buf, "FAIL\t%s\t%d of %d test(s) failed\n", file, failed, len(grouped[file]))
+		} else {
+			fmt.Fprintf(buf, "ok\t%s\t%d test(s)\n", file, len(grouped[file]))
+		}
+	}
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",cdata"`
+}
+
+// WriteJUnit writes the results as JUnit XML with a test suite for each file
+func WriteJUnit(w io.Writer, results []Result) error {
+	var (
+		files, grouped = byFile(results)
+		doc            = junitTestSuites{
+			Tests:    len(results),
+			Failures: Failed(results),
+		}
+		total time.Duration
+	)
+
+	for _, file := range files {
+		suite := junitTestSuite{
+			Name:     file,
+			Tests:    len(grouped[file]),
+			Failures: Failed(grouped[file]),
+		}
+		var suiteTime time.Duration
+		for _, result := range grouped[file] {
+			testCase := junitTestCase{
+				Name:      result.Name,
+				ClassName: file,
+				Time:      seconds(result.Duration),
+			}
+			if !result.Passed() {
+				message, _, _ := strings.Cut(result.Failure, "\n")
+				testCase.Failure = &junitFailure{
+					Message:  message,
+					Contents: result.Pos.String() + "\n" + result.Failure,
+				}
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+			suiteTime += result.Duration
+		}
+		suite.Time = seconds(suiteTime)
+		doc.Suites = append(doc.Suites, suite)
+		total += suiteTime
+	}
+	doc.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,60 @@
+package cmds
+
+import (
+	"strings"
+
+	"github.com/acorn-io/aml/cli/pkg/flagargs"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// completeFileArgs completes the FILE argument of a command and after it the flags generated
+// from the args of the file, the names of the profiles and the values of enum args.
+func completeFileArgs(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	flags, err := flagargs.Load(args[0], flagargs.Options{})
+	if err != nil {
+		cobra.CompDebugln(err.Error(), true)
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// the value of --name=value
+	if name, prefix, ok := strings.Cut(strings.TrimPrefix(toComplete, "--"), "="); ok && strings.HasPrefix(toComplete, "--") {
+		var result []string
+		for _, v := range filterPrefix(flags.Values(name), prefix) {
+			result = append(result, "--"+name+"="+v)
+		}
+		return result, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	// the value of --name value
+	if last := args[len(args)-1]; len(args) > 1 && strings.HasPrefix(last, "--") && !strings.Contains(last, "=") {
+		if flag := flags.FlagSet.Lookup(last[2:]); flag != nil && flag.NoOptDefVal == "" {
+			if values := flags.Values(flag.Name); len(values) > 0 {
+				return filterPrefix(values, toComplete), cobra.ShellCompDirectiveNoFileComp
+			}
+			// values can be read from a file with @FILE
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+	}
+
+	var result []string
+	flags.FlagSet.VisitAll(func(flag *pflag.Flag) {
+		if name := "--" + flag.Name; strings.HasPrefix(name, toComplete) {
+			result = append(result, name+"\t"+flag.Usage)
+		}
+	})
+	return result, cobra.ShellCompDirectiveNoFileComp
+}
+
+func filterPrefix(values []string, prefix string) (result []string) {
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,44 @@
+package cmds
+
+import (
+	"os"
+	"time"
+
+	"github.com/acorn-io/aml/cli/pkg/coverage"
+	"github.com/acorn-io/aml/cli/pkg/profile"
+	"github.com/acorn-io/aml/pkg/eval"
+)
+
+// writeCoverage writes the coverage report to file, or to stderr if file is -
+func writeCoverage(file, format string, cov *eval.Coverage) error {
+	if file == "-" {
+		return coverage.Write(os.Stderr, format, cov.Blocks())
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := coverage.Write(f, format, cov.Blocks()); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// writeProfile writes the evaluation profile to file, or to stderr if file is -
+func writeProfile(file, format string, profiler *eval.Profiler, start time.Time) error {
+	if file == "-" {
+		return profile.Write(os.Stderr, format, profiler.Samples(), start, profiler.Duration())
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := profile.Write(f, format, profiler.Samples(), start, profiler.Duration()); err != nil {
+		return err
+	}
+	return f.Close()
+}
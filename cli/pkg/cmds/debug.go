@@ -0,0 +1,102 @@
+package cmds
+
+import (
+	"errors"
+	"os"
+
+	"github.com/acorn-io/aml"
+	"github.com/acorn-io/aml/cli/pkg/debugger"
+	"github.com/acorn-io/aml/cli/pkg/flagargs"
+	"github.com/acorn-io/aml/pkg/eval"
+	"github.com/acorn-io/aml/pkg/value"
+	"github.com/acorn-io/cmd"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+type Debug struct {
+	aml *AML
+
+	Break     []string `usage:"Pause before evaluating the fields and function calls on this line, FILE:LINE or LINE of FILE"`
+	Continue  bool     `usage:"Run until the first breakpoint instead of pausing at the first field"`
+	ArgsFile  []string `usage:"Files of default arguments to pass, a later file replaces whole top level values of earlier ones (default .args.acorn)"`
+	EnvPrefix string   `usage:"Prefix of the environment variables to read arguments from, nested args use __ for dots, empty to disable" default:"AML_ARG_"`
+	KeyFile   string   `usage:"File of the key used by std.decrypt to decrypt values, see aml encrypt"`
+	Output    string   `usage:"Output format of the result (json, yaml, aml, toml, env, properties)" short:"o" default:"json"`
+}
+
+func NewDebug(aml *AML) *cobra.Command {
+	return cmd.Command(&Debug{aml: aml}, cobra.Command{
+		Use:   "debug [flags] FILE",
+		Short: "Evaluate a file in a step debugger",
+		Long: `Evaluate a file in a step debugger
+
+The evaluation pauses before the first field is evaluated and commands are read from stdin.
+Set breakpoints on FILE:LINE, step into function calls, print the call stack, the values
+in the scopes of the current field and evaluate expressions in the current scope. Type
+help at the prompt for the list of commands. The result is printed when the evaluation
+finishes. Arguments of the file are passed as with aml eval.`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeFileArgs,
+		SilenceErrors:     true,
+	})
+}
+
+func (d *Debug) Customize(cmd *cobra.Command) {
+	cmd.Flags().SetInterspersed(false)
+}
+
+func (d *Debug) Run(cmd *cobra.Command, args []string) error {
+	filename := args[0]
+	args = args[1:]
+
+	var breakpoints []debugger.Breakpoint
+	for _, arg := range d.Break {
+		b, err := debugger.ParseBreakpoint(arg, filename)
+		if err != nil {
+			return err
+		}
+		breakpoints = append(breakpoints, b)
+	}
+
+	flags, err := flagargs.Load(filename, argsOptions(d.ArgsFile, d.EnvPrefix))
+	if err != nil {
+		return err
+	}
+	argsData, profiles, err := flags.Parse(args)
+	if errors.Is(err, pflag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	key, err := readKeyFile(d.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	dbg := debugger.New(os.Stdin, cmd.OutOrStdout(), debugger.Options{
+		Breakpoints: breakpoints,
+		Continue:    d.Continue,
+	})
+
+	var out value.Value
+	err = aml.Unmarshal(data, &out, aml.DecoderOption{
+		SourceName:    filename,
+		Args:          argsData,
+		Profiles:      profiles,
+		Context:       eval.WithDebugger(cmd.Context(), dbg),
+		DecryptionKey: key,
+	})
+	if errors.Is(err, debugger.ErrQuit) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return d.aml.Output(d.Output, out)
+}
@@ -0,0 +1,121 @@
+package cmds
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/acorn-io/aml"
+	"github.com/acorn-io/aml/cli/pkg/diff"
+	"github.com/acorn-io/aml/cli/pkg/flagargs"
+	"github.com/acorn-io/aml/pkg/value"
+	"github.com/acorn-io/cmd"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+type Diff struct {
+	aml *AML
+
+	ArgsFile  []string `usage:"Files of default arguments to pass to both files, a later file replaces whole top level values of earlier ones (default .args.acorn)"`
+	EnvPrefix string   `usage:"Prefix of the environment variables to read arguments from, nested args use __ for dots, empty to disable" default:"AML_ARG_"`
+	OldArgs   string   `usage:"Arguments for the old file as they would be passed to eval, for example '--replicas 2 --profile prod'"`
+	NewArgs   string   `usage:"Arguments for the new file as they would be passed to eval"`
+	ArrayKey  string   `usage:"Match elements of arrays of objects by the value of this field instead of by index"`
+	Output    string   `usage:"Output format (text, json-patch)" short:"o" default:"text"`
+	ExitCode  bool     `usage:"Exit like diff(1), with status 0 if there are no differences, 1 if there are and 2 on errors"`
+	KeyFile   string   `usage:"File of the key used by std.decrypt to decrypt values in both files"`
+}
+
+func NewDiff(aml *AML) *cobra.Command {
+	return cmd.Command(&Diff{aml: aml}, cobra.Command{
+		Use:           "diff [flags] OLD_FILE NEW_FILE",
+		Short:         "Evaluate two files and print the differences between the results",
+		Args:          cobra.ExactArgs(2),
+		SilenceErrors: true,
+	})
+}
+
+func (d *Diff) Run(cmd *cobra.Command, args []string) error {
+	changed, err := d.diff(cmd, args)
+	if err != nil && d.ExitCode {
+		// errors exit with 2 so that they are not mistaken for differences
+		return &ExitCodeError{Code: 2, Err: err}
+	} else if err != nil {
+		return err
+	}
+
+	if d.ExitCode && changed {
+		return &ExitCodeError{Code: 1}
+	}
+	return nil
+}
+
+// diff prints the differences between the files, true is returned if there are any
+func (d *Diff) diff(cmd *cobra.Command, args []string) (bool, error) {
+	var patch bool
+	switch d.Output {
+	case "text":
+	case "json-patch":
+		patch = true
+	default:
+		return false, fmt.Errorf("invalid output format %q, must be text or json-patch", d.Output)
+	}
+
+	oldValue, err := d.eval(cmd, args[0], d.OldArgs)
+	if err != nil {
+		return false, err
+	}
+
+	newValue, err := d.eval(cmd, args[1], d.NewArgs)
+	if err != nil {
+		return false, err
+	}
+
+	changes, err := diff.Values(oldValue, newValue, diff.Options{
+		ArrayKey: d.ArrayKey,
+		Patch:    patch,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if patch {
+		err = diff.WriteJSONPatch(os.Stdout, changes)
+	} else {
+		err = diff.WriteText(os.Stdout, changes)
+	}
+	if err != nil {
+		return false, err
+	}
+	return len(changes) > 0, nil
+}
+
+func (d *Diff) eval(cmd *cobra.Command, filename, args string) (value.Value, error) {
+	argsData, profiles, err := flagargs.ParseArgs(filename, strings.Fields(args), argsOptions(d.ArgsFile, d.EnvPrefix))
+	if errors.Is(err, pflag.ErrHelp) {
+		return nil, fmt.Errorf("help requested for the args of %s", filename)
+	} else if err != nil {
+		return nil, fmt.Errorf("parsing args for %s: %w", filename, err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := readKeyFile(d.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var result value.Value
+	return result, aml.Unmarshal(data, &result, aml.DecoderOption{
+		SourceName:    filename,
+		Args:          argsData,
+		Profiles:      profiles,
+		Context:       cmd.Context(),
+		DecryptionKey: key,
+	})
+}
@@ -0,0 +1,68 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/acorn-io/aml"
+	"github.com/acorn-io/aml/cli/pkg/doc"
+	"github.com/acorn-io/aml/pkg/schema"
+	"github.com/acorn-io/cmd"
+	"github.com/spf13/cobra"
+)
+
+type Doc struct {
+	aml *AML
+
+	Output   string `usage:"Output format (markdown, html)" short:"o" default:"markdown"`
+	Title    string `usage:"Title of the document, defaults to the file name"`
+	NoOutput bool   `usage:"Do not document the output schema of the file"`
+}
+
+func NewDoc(aml *AML) *cobra.Command {
+	return cmd.Command(&Doc{aml: aml}, cobra.Command{
+		Use:           "doc [flags] FILE",
+		Short:         "Generates documentation of the args, profiles and output schema of a file",
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+	})
+}
+
+func (d *Doc) Run(cmd *cobra.Command, args []string) error {
+	filename := args[0]
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	opt := aml.DecoderOption{
+		SourceName: filename,
+		Context:    cmd.Context(),
+	}
+
+	file := &schema.File{}
+	if err := aml.Unmarshal(data, file, opt); err != nil {
+		return err
+	}
+
+	var summary *schema.Summary
+	if !d.NoOutput {
+		// the output is described with placeholders for the args that have no default
+		opt.Args = doc.Placeholders(file.Args)
+		summary = &schema.Summary{}
+		if err := aml.Unmarshal(data, summary, opt); err != nil {
+			return fmt.Errorf("describing the output with placeholder args, use --no-output to skip it: %w", err)
+		}
+	}
+
+	title := d.Title
+	if title == "" {
+		title = filepath.Base(filename)
+	}
+
+	return doc.Write(os.Stdout, file, summary, doc.Options{
+		Title:  title,
+		Format: d.Output,
+	})
+}
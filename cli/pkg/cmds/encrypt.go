@@ -0,0 +1,170 @@
+package cmds
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/acorn-io/aml/pkg/encrypt"
+	"github.com/acorn-io/cmd"
+	"github.com/spf13/cobra"
+)
+
+type Encrypt struct {
+	aml *AML
+
+	KeyFile     string `usage:"File of the key to encrypt with" short:"k"`
+	GenerateKey bool   `usage:"Write a new random key to --key-file, the file must not already exist"`
+}
+
+func NewEncrypt(aml *AML) *cobra.Command {
+	return cmd.Command(&Encrypt{aml: aml}, cobra.Command{
+		Use:   "encrypt [flags] [FILE...]",
+		Short: "Encrypts the values passed to std.decrypt in files",
+		Long: `Encrypts the values passed to std.decrypt in files
+
+Each string literal passed to std.decrypt that is not yet encrypted is replaced by its
+ciphertext in place, the rest of the file is left unchanged. If no FILE is given a value
+is read from stdin and its ciphertext is printed.
+
+Values are encrypted with AES-256-GCM using the key in --key-file, which is created with
+--generate-key. Pass the same key to aml eval --key-file to decrypt the values.`,
+		SilenceErrors: true,
+	})
+}
+
+func (e *Encrypt) Run(cmd *cobra.Command, args []string) error {
+	if e.KeyFile == "" {
+		return fmt.Errorf("--key-file is required")
+	}
+
+	var (
+		key []byte
+		err error
+	)
+	if e.GenerateKey {
+		key, err = encrypt.WriteKeyFile(e.KeyFile)
+		if err != nil {
+			return fmt.Errorf("generating key: %w", err)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "wrote key %s to %s\n", encrypt.KeyID(key), e.KeyFile)
+		if len(args) == 0 {
+			return nil
+		}
+	} else {
+		key, err = encrypt.ReadKeyFile(e.KeyFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(args) == 0 {
+		data, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+		ciphertext, err := encrypt.Encrypt(key, strings.TrimSuffix(string(data), "\n"))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(cmd.OutOrStdout(), ciphertext)
+		return err
+	}
+
+	return rewriteFiles(cmd, args, func(s string) (string, bool, error) {
+		if encrypt.IsEncrypted(s) {
+			return "", false, nil
+		}
+		ciphertext, err := encrypt.Encrypt(key, s)
+		return ciphertext, err == nil, err
+	})
+}
+
+type Rotate struct {
+	aml *AML
+
+	KeyFile    string `usage:"File of the key the values are currently encrypted with" short:"k"`
+	NewKeyFile string `usage:"File of the key to encrypt the values with"`
+}
+
+func NewRotate(aml *AML) *cobra.Command {
+	return cmd.Command(&Rotate{aml: aml}, cobra.Command{
+		Use:   "rotate [flags] FILE...",
+		Short: "Re-encrypts the encrypted values in files with a new key",
+		Long: `Re-encrypts the encrypted values in files with a new key
+
+Each encrypted string literal passed to std.decrypt is decrypted with --key-file and
+replaced in place by its ciphertext from --new-key-file. Values already encrypted with
+the new key are left unchanged.`,
+		Args:          cobra.MinimumNArgs(1),
+		SilenceErrors: true,
+	})
+}
+
+func (r *Rotate) Run(cmd *cobra.Command, args []string) error {
+	if r.KeyFile == "" || r.NewKeyFile == "" {
+		return fmt.Errorf("--key-file and --new-key-file are required")
+	}
+
+	oldKey, err := encrypt.ReadKeyFile(r.KeyFile)
+	if err != nil {
+		return err
+	}
+	newKey, err := encrypt.ReadKeyFile(r.NewKeyFile)
+	if err != nil {
+		return err
+	}
+	newPrefix := encrypt.Prefix + encrypt.KeyID(newKey) + ":"
+
+	return rewriteFiles(cmd, args, func(s string) (string, bool, error) {
+		if !encrypt.IsEncrypted(s) || strings.HasPrefix(s, newPrefix) {
+			return "", false, nil
+		}
+		plaintext, err := encrypt.Decrypt(oldKey, s)
+		if err != nil {
+			return "", false, err
+		}
+		ciphertext, err := encrypt.Encrypt(newKey, plaintext)
+		return ciphertext, err == nil, err
+	})
+}
+
+// rewriteFiles replaces the literals passed to std.decrypt in each file with the result of f and
+// writes the files that changed
+func rewriteFiles(cmd *cobra.Command, files []string, f func(string) (string, bool, error)) error {
+	var errs []error
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reading %s: %w", file, err))
+			continue
+		}
+
+		newData, n, err := encrypt.Rewrite(file, data, f)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rewriting %s: %w", file, err))
+			continue
+		}
+
+		if !bytes.Equal(data, newData) {
+			if err := os.WriteFile(file, newData, 0644); err != nil {
+				errs = append(errs, fmt.Errorf("writing file %s: %w", file, err))
+				continue
+			}
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "%s: %d value(s) updated\n", file, n)
+	}
+
+	return errors.Join(errs...)
+}
+
+// readKeyFile reads the key used to decrypt values, no key is returned if keyFile is empty
+func readKeyFile(keyFile string) ([]byte, error) {
+	if keyFile == "" {
+		return nil, nil
+	}
+	return encrypt.ReadKeyFile(keyFile)
+}
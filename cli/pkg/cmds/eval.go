@@ -2,12 +2,21 @@ package cmds
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/acorn-io/aml"
+	"github.com/acorn-io/aml/cli/pkg/diff"
 	"github.com/acorn-io/aml/cli/pkg/flagargs"
+	"github.com/acorn-io/aml/cli/pkg/prompt"
+	"github.com/acorn-io/aml/cli/pkg/watch"
+	"github.com/acorn-io/aml/pkg/ast"
+	"github.com/acorn-io/aml/pkg/eval"
 	"github.com/acorn-io/aml/pkg/schema"
+	"github.com/acorn-io/aml/pkg/value"
 	"github.com/acorn-io/cmd"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -16,18 +25,42 @@ import (
 type Eval struct {
 	aml *AML
 
-	ArgsFile    string `usage:"Default arguments to pass" default:".args.acorn"`
-	PrintArgs   bool   `usage:"Evaluate the file and print args description"`
-	PrintSchema bool   `usage:"Evaluate the file as schema and print schema description"`
-	SchemaFile  string `usage:"Validate result against schema file"`
+	ArgsFile           []string `usage:"Files of default arguments to pass, a later file replaces whole top level values of earlier ones (default .args.acorn)"`
+	EnvPrefix          string   `usage:"Prefix of the environment variables to read arguments from, nested args use __ for dots, empty to disable" default:"AML_ARG_"`
+	PrintArgs          bool     `usage:"Evaluate the file and print args description"`
+	PrintEffectiveArgs bool     `usage:"Print the value of each argument and where it was set"`
+	PrintSchema        bool     `usage:"Evaluate the file as schema and print schema description"`
+	SchemaFile         string   `usage:"Validate result against schema file"`
+	Output             string   `usage:"Output format (json, yaml, aml, toml, env, properties)" short:"o" default:"json"`
+	Path               string   `usage:"Only output the value at this dotted path of the result, for example containers.web.image"`
+	Expr               string   `usage:"Output the value of this expression evaluated against the result, for example 'keys(containers)'"`
+	Watch              bool     `usage:"Re-evaluate and print the output each time the file, args files, schema file or key file changes"`
+	WatchDiff          bool     `usage:"With --watch, print the changes from the previous result instead of the full output"`
+	Interactive        bool     `usage:"Prompt for the value of each required argument that is not set"`
+	SaveArgs           bool     `usage:"With --interactive, write the answers to the first args file"`
+	KeyFile            string   `usage:"File of the key used by std.decrypt to decrypt values, see aml encrypt"`
+	Coverage           string   `usage:"Write a report of the fields, branches, loops and functions of the file that were evaluated to this file, - for stderr"`
+	CoverageFormat     string   `usage:"Format of the coverage report (text, html, go)" default:"text"`
+	ProfileEval        string   `usage:"Write a profile of the time and allocations spent evaluating each field, loop and function call to this file, - for stderr"`
+	ProfileFormat      string   `usage:"Format of the evaluation profile, pprof for go tool pprof or text" default:"pprof"`
 }
 
 func NewEval(aml *AML) *cobra.Command {
 	return cmd.Command(&Eval{aml: aml}, cobra.Command{
-		Use:           "eval [flags] FILE",
-		Short:         "Evaluate a file and output the result",
-		Args:          cobra.MinimumNArgs(1),
-		SilenceErrors: true,
+		Use:   "eval [flags] FILE",
+		Short: "Evaluate a file and output the result",
+		Long: `Evaluate a file and output the result
+
+Arguments of the file are taken from the following sources, each source overriding the
+sources before it: the defaults in the file, the selected profiles, the args files in
+order, environment variables such as AML_ARG_replicas=3, and the flags after FILE.
+
+A later args file replaces the whole value of a top level argument set by an earlier
+one, objects are not merged. The environment variable of a nested argument writes the
+dots of its name as __ and its case is ignored, so AML_ARG_DB__HOST=db sets db.host.`,
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeFileArgs,
+		SilenceErrors:     true,
 	})
 }
 
@@ -39,20 +72,188 @@ func (e *Eval) Run(cmd *cobra.Command, args []string) error {
 	filename := args[0]
 	args = args[1:]
 
-	argsData, profiles, err := flagargs.ParseArgs(e.ArgsFile, filename, args)
+	if e.Path != "" && e.Expr != "" {
+		return fmt.Errorf("--path and --expr can not be used together")
+	}
+
+	if e.Interactive && e.Watch {
+		return fmt.Errorf("--interactive and --watch can not be used together")
+	}
+
+	if e.PrintEffectiveArgs {
+		return e.printEffectiveArgs(filename, args)
+	}
+
+	if e.Watch {
+		return e.watch(cmd, filename, args)
+	}
+
+	out, err := e.evaluate(cmd, filename, args)
+	if err != nil || out == nil {
+		return err
+	}
+	return e.aml.Output(e.Output, out)
+}
+
+func (e *Eval) printEffectiveArgs(filename string, args []string) error {
+	flags, err := flagargs.Load(filename, argsOptions(e.ArgsFile, e.EnvPrefix))
+	if err != nil {
+		return err
+	}
+
+	argsData, profiles, err := flags.Parse(args)
 	if errors.Is(err, pflag.ErrHelp) {
 		return nil
 	} else if err != nil {
 		return err
 	}
 
-	data, err := os.ReadFile(filename)
+	return e.aml.Output(e.Output, flags.EffectiveArgs(argsData, profiles))
+}
+
+// argsOptions returns the options to read args with, .args.acorn is read if it exists and no args
+// files are given. The args files that are given must exist.
+func argsOptions(argsFiles []string, envPrefix string) flagargs.Options {
+	if len(argsFiles) == 0 {
+		return flagargs.Options{
+			ArgsFiles: []string{".args.acorn"},
+			EnvPrefix: envPrefix,
+		}
+	}
+	return flagargs.Options{
+		ArgsFiles:        argsFiles,
+		RequireArgsFiles: true,
+		EnvPrefix:        envPrefix,
+	}
+}
+
+// parseArgs returns the args and profiles for the file. With --interactive the value of each
+// required arg that is not set is asked for on stdin.
+func (e *Eval) parseArgs(filename string, args []string) (map[string]any, []string, error) {
+	opts := argsOptions(e.ArgsFile, e.EnvPrefix)
+	if e.Interactive && e.SaveArgs {
+		// the first args file is created by --save-args
+		opts.RequireArgsFiles = false
+	}
+	flags, err := flagargs.Load(filename, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	argsData, profiles, err := flags.Parse(args)
+	if err != nil || !e.Interactive {
+		return argsData, profiles, err
+	}
+
+	missing := flags.Missing(argsData, profiles)
+	if len(missing) == 0 {
+		return argsData, profiles, nil
+	}
+
+	answers, err := prompt.Args(os.Stdin, os.Stderr, flags, missing)
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, v := range answers {
+		argsData[name] = v
+	}
+
+	if e.SaveArgs {
+		if err := saveArgs(opts.ArgsFiles[0], answers); err != nil {
+			return nil, nil, err
+		}
+	}
+	return argsData, profiles, nil
+}
+
+// saveArgs adds the values to the args file, creating it if it does not exist. The file is
+// rewritten so comments in an existing file are not kept.
+func saveArgs(argsFile string, values map[string]any) error {
+	existing := map[string]any{}
+	if data, err := os.ReadFile(argsFile); err == nil {
+		if err := aml.Unmarshal(data, &existing, aml.DecoderOption{
+			SourceName: argsFile,
+		}); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	for name, v := range values {
+		existing[name] = v
+	}
+
+	data, err := aml.Marshal(existing, aml.EncoderOption{
+		SortKeys: true,
+	})
 	if err != nil {
 		return err
 	}
+	return os.WriteFile(argsFile, data, 0644)
+}
+
+// watch evaluates the file each time it, the args file or the schema file changes. Errors are
+// printed and evaluation is retried on the next change.
+func (e *Eval) watch(cmd *cobra.Command, filename string, args []string) error {
+	files := append([]string{filename}, argsOptions(e.ArgsFile, e.EnvPrefix).ArgsFiles...)
+	if e.SchemaFile != "" {
+		files = append(files, e.SchemaFile)
+	}
+	if e.KeyFile != "" {
+		files = append(files, e.KeyFile)
+	}
+
+	var last value.Value
+	return watch.Poll(cmd.Context(), files, watch.DefaultInterval, os.Stderr, func() error {
+		out, err := e.evaluate(cmd, filename, args)
+		if err != nil || out == nil {
+			return err
+		}
+
+		v, ok := out.(value.Value)
+		if !e.WatchDiff || !ok || last == nil {
+			if ok {
+				last = v
+			}
+			return e.aml.Output(e.Output, out)
+		}
+
+		changes, err := diff.Values(last, v, diff.Options{})
+		if err != nil {
+			return err
+		}
+		last = v
+		if len(changes) == 0 {
+			fmt.Fprintln(os.Stderr, "no changes")
+			return nil
+		}
+		return diff.WriteText(os.Stdout, changes)
+	})
+}
+
+// evaluate returns the result of evaluating the file. A nil result with no error is returned if
+// help was requested for the args.
+func (e *Eval) evaluate(cmd *cobra.Command, filename string, args []string) (_ any, retErr error) {
+	argsData, profiles, err := e.parseArgs(filename, args)
+	if errors.Is(err, pflag.ErrHelp) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	if e.Path != "" {
+		paths = append(paths, e.Path)
+	}
 
 	var (
-		out         any = &map[string]any{}
+		out         any = new(value.Value)
 		schemaInput io.Reader
 	)
 	if e.PrintArgs {
@@ -62,10 +263,45 @@ func (e *Eval) Run(cmd *cobra.Command, args []string) error {
 	}
 
 	if e.SchemaFile != "" {
-		schemaInput, err = os.Open(e.SchemaFile)
+		schemaFile, err := os.Open(e.SchemaFile)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		defer schemaFile.Close()
+		schemaInput = schemaFile
+	}
+
+	key, err := readKeyFile(e.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := cmd.Context()
+	if e.Coverage != "" {
+		var parsed ast.File
+		if err := aml.Unmarshal(data, &parsed, aml.DecoderOption{
+			SourceName: filename,
+		}); err != nil {
+			return nil, err
+		}
+		cov := eval.NewCoverage()
+		cov.AddFile(&parsed)
+		ctx = eval.WithCoverage(ctx, cov)
+		defer func() {
+			if coverageErr := writeCoverage(e.Coverage, e.CoverageFormat, cov); retErr == nil {
+				retErr = coverageErr
+			}
+		}()
+	}
+	if e.ProfileEval != "" {
+		profiler := eval.NewProfiler()
+		start := time.Now()
+		ctx = eval.WithProfiler(ctx, profiler)
+		defer func() {
+			if profileErr := writeProfile(e.ProfileEval, e.ProfileFormat, profiler, start); retErr == nil {
+				retErr = profileErr
+			}
+		}()
 	}
 
 	err = aml.Unmarshal(data, out, aml.DecoderOption{
@@ -74,11 +310,26 @@ func (e *Eval) Run(cmd *cobra.Command, args []string) error {
 		SourceName:       filename,
 		Args:             argsData,
 		Profiles:         profiles,
-		Context:          cmd.Context(),
+		Context:          ctx,
+		Expr:             e.Expr,
+		Paths:            paths,
+		DecryptionKey:    key,
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return e.aml.Output(out)
+	if v, ok := out.(*value.Value); ok {
+		out = *v
+		if e.Path != "" {
+			found, ok, err := eval.LookupPath(*v, strings.Split(e.Path, "."))
+			if err != nil {
+				return nil, err
+			} else if !ok {
+				return nil, fmt.Errorf("path %s not found in %s", e.Path, filename)
+			}
+			out = found
+		}
+	}
+	return out, nil
 }
@@ -7,12 +7,15 @@ import (
 	"os"
 
 	"github.com/acorn-io/aml"
+	"github.com/acorn-io/aml/cli/pkg/watch"
 	"github.com/acorn-io/cmd"
 	"github.com/spf13/cobra"
 )
 
 type Fmt struct {
 	aml *AML
+
+	Watch bool `usage:"Format the files again each time they change"`
 }
 
 func NewFmt(aml *AML) *cobra.Command {
@@ -24,6 +27,15 @@ func NewFmt(aml *AML) *cobra.Command {
 }
 
 func (e *Fmt) Run(cmd *cobra.Command, args []string) error {
+	if e.Watch {
+		return watch.Poll(cmd.Context(), args, watch.DefaultInterval, os.Stderr, func() error {
+			return e.format(args)
+		})
+	}
+	return e.format(args)
+}
+
+func (e *Fmt) format(args []string) error {
 	var errs []error
 	for _, arg := range args {
 		data, err := os.ReadFile(arg)
@@ -41,7 +53,7 @@ func (e *Fmt) Run(cmd *cobra.Command, args []string) error {
 		if !bytes.Equal(data, newData) {
 			err := os.WriteFile(arg, newData, 0644)
 			if err != nil {
-				errs = append(errs, fmt.Errorf("writing file %s: %w", args, err))
+				errs = append(errs, fmt.Errorf("writing file %s: %w", arg, err))
 			}
 			continue
 		}
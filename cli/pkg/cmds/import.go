@@ -0,0 +1,43 @@
+package cmds
+
+import (
+	"os"
+
+	"github.com/acorn-io/aml/cli/pkg/amlimport"
+	"github.com/acorn-io/cmd"
+	"github.com/spf13/cobra"
+)
+
+type Import struct {
+	aml *AML
+
+	Key    string `usage:"For multi-document YAML, output an object keyed by the value of this dotted path in each document instead of an array"`
+	Format string `usage:"Format of the input (yaml, json, toml), defaults to the file extension"`
+}
+
+func NewImport(aml *AML) *cobra.Command {
+	return cmd.Command(&Import{aml: aml}, cobra.Command{
+		Use:           "import [flags] FILE",
+		Short:         "Converts a YAML, JSON, or TOML file to AML, writing the result to stdout",
+		Args:          cobra.ExactArgs(1),
+		SilenceErrors: true,
+	})
+}
+
+func (i *Import) Run(cmd *cobra.Command, args []string) error {
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	out, err := amlimport.Convert(args[0], data, amlimport.Options{
+		Key:    i.Key,
+		Format: i.Format,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(out)
+	return err
+}
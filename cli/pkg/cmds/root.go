@@ -1,9 +1,14 @@
 package cmds
 
 import (
-	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
 
+	"github.com/acorn-io/aml/cli/pkg/output"
+	"github.com/acorn-io/aml/pkg/eval"
+	"github.com/acorn-io/aml/pkg/value"
 	"github.com/acorn-io/cmd"
 	"github.com/spf13/cobra"
 )
@@ -17,20 +22,83 @@ func NewRootCommand() *cobra.Command {
 	})
 }
 
+// ExitCodeError is returned by commands that exit with a status other than 1. Err is printed
+// before exiting if it is set.
+type ExitCodeError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitCodeError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitCodeError) Unwrap() error {
+	return e.Err
+}
+
 type AML struct {
+	RevealSecrets    bool   `usage:"Do not redact secret values in output, errors and debug messages"`
+	Debug            bool   `usage:"Print the messages of debug() calls"`
+	LogFormat        string `usage:"Format of debug messages and warnings printed to stderr (text, json)" default:"text"`
+	WarningsAsErrors bool   `usage:"Fail the evaluation at the first warning, such as an arg that is deprecated or not defined"`
+}
+
+func (a *AML) PersistentPre(cmd *cobra.Command, args []string) error {
+	value.RevealSecrets = a.RevealSecrets
+
+	h, err := a.logHandler(os.Stderr)
+	if err != nil {
+		return err
+	}
+	ctx := eval.WithLogHandler(cmd.Context(), h)
+	ctx = eval.WithWarnings(ctx, &eval.Warnings{
+		AsErrors: a.WarningsAsErrors,
+	})
+	cmd.SetContext(ctx)
+	return nil
+}
+
+// logHandler returns the handler for the debug messages and warnings of evaluations, debug
+// messages are dropped unless --debug is set
+func (a *AML) logHandler(w io.Writer) (slog.Handler, error) {
+	level := slog.LevelInfo
+	if a.Debug {
+		level = slog.LevelDebug
+	}
+	opts := &slog.HandlerOptions{
+		Level: level,
+	}
+	switch a.LogFormat {
+	case "text":
+		return slog.NewTextHandler(w, opts), nil
+	case "json":
+		return slog.NewJSONHandler(w, opts), nil
+	}
+	return nil, fmt.Errorf("unknown log format %s, must be text or json", a.LogFormat)
 }
 
 func (a *AML) Customize(cmd *cobra.Command) {
 	cmd.AddCommand(NewEval(a))
 	cmd.AddCommand(NewFmt(a))
+	cmd.AddCommand(NewImport(a))
+	cmd.AddCommand(NewDiff(a))
+	cmd.AddCommand(NewServe(a))
+	cmd.AddCommand(NewDoc(a))
+	cmd.AddCommand(NewEncrypt(a))
+	cmd.AddCommand(NewRotate(a))
+	cmd.AddCommand(NewTest(a))
+	cmd.AddCommand(NewSnapshot(a))
+	cmd.AddCommand(NewDebug(a))
 }
 
 func (a *AML) Run(cmd *cobra.Command, args []string) error {
 	return cmd.Usage()
 }
 
-func (a *AML) Output(data any) error {
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "    ")
-	return enc.Encode(data)
+func (a *AML) Output(format string, data any) error {
+	return output.Write(os.Stdout, format, data)
 }
@@ -0,0 +1,72 @@
+package cmds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/acorn-io/aml/cli/pkg/server"
+	"github.com/acorn-io/aml/pkg/eval"
+	"github.com/acorn-io/cmd"
+	"github.com/spf13/cobra"
+)
+
+type Serve struct {
+	aml *AML
+
+	Listen          string `usage:"Address to listen on" default:"127.0.0.1:8080"`
+	Timeout         string `usage:"Default and maximum duration of an evaluation" default:"10s"`
+	MaxDepth        int    `usage:"Default and maximum depth of nested scopes and function calls" default:"100"`
+	MaxRequestBytes int    `usage:"Maximum size of a request body" default:"1048576"`
+}
+
+func NewServe(aml *AML) *cobra.Command {
+	return cmd.Command(&Serve{aml: aml}, cobra.Command{
+		Use:           "serve [flags]",
+		Short:         "Serves an HTTP API to evaluate, describe, format and validate files",
+		Args:          cobra.NoArgs,
+		SilenceErrors: true,
+	})
+}
+
+func (s *Serve) Run(cmd *cobra.Command, args []string) error {
+	timeout, err := time.ParseDuration(s.Timeout)
+	if err != nil {
+		return fmt.Errorf("invalid --timeout: %w", err)
+	}
+	if s.MaxDepth > eval.MaxCallDepth {
+		return fmt.Errorf("--max-depth %d can not be greater than %d", s.MaxDepth, eval.MaxCallDepth)
+	}
+
+	l, err := net.Listen("tcp", s.Listen)
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Handler: server.NewHandler(server.Options{
+			Timeout:         timeout,
+			MaxDepth:        s.MaxDepth,
+			MaxRequestBytes: int64(s.MaxRequestBytes),
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx := cmd.Context()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Listening on http://%s\n", l.Addr())
+	if err := srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package cmds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/acorn-io/aml/cli/pkg/snapshot"
+	"github.com/acorn-io/cmd"
+	"github.com/spf13/cobra"
+)
+
+type Snapshot struct {
+	aml *AML
+
+	Update  bool   `usage:"Write the result of each file to its golden file instead of comparing them"`
+	KeyFile string `usage:"File of the key used by std.decrypt to decrypt values, see aml encrypt"`
+}
+
+func NewSnapshot(aml *AML) *cobra.Command {
+	return cmd.Command(&Snapshot{aml: aml}, cobra.Command{
+		Use:   "snapshot [flags] [PATH...]",
+		Short: "Compares the result of files with their golden files",
+		Long: `Compares the result of files with their golden files
+
+Each .acorn file in the directories, or the current directory if no PATH is given, is
+evaluated and its result is compared with the .golden file next to it. If the evaluation
+fails the error is compared instead. Args files and *_test.acorn files are skipped.
+
+A file NAME.acorn is evaluated with the args in NAME.args.acorn and the profiles listed in
+NAME.profiles if those files exist. Use --update to create or rewrite the golden files.`,
+		SilenceErrors: true,
+	})
+}
+
+func (s *Snapshot) Run(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	files, err := snapshot.Files(args)
+	if err != nil {
+		return err
+	}
+
+	key, err := readKeyFile(s.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	results, err := snapshot.Run(cmd.Context(), files, snapshot.Options{
+		Update:        s.Update,
+		DecryptionKey: key,
+	})
+	if err != nil {
+		return err
+	}
+
+	var failed int
+	for _, result := range results {
+		switch result.Status {
+		case snapshot.Updated:
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", result.Status, result.Golden)
+		case snapshot.Failed:
+			failed++
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", result.Status, result.File)
+			for _, line := range strings.Split(result.Diff, "\n") {
+				fmt.Fprintf(cmd.OutOrStdout(), "    %s\n", line)
+			}
+		default:
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", result.Status, result.File)
+		}
+	}
+
+	if len(results) == 0 {
+		return fmt.Errorf("no files found")
+	} else if failed > 0 {
+		return fmt.Errorf("%d of %d snapshot(s) do not match, run with --update to accept the changes", failed, len(results))
+	}
+	return nil
+}
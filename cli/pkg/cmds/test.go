@@ -0,0 +1,104 @@
+package cmds
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/acorn-io/aml/cli/pkg/amltest"
+	"github.com/acorn-io/aml/pkg/eval"
+	"github.com/acorn-io/cmd"
+	"github.com/spf13/cobra"
+)
+
+type Test struct {
+	aml *AML
+
+	RunExpr string `usage:"Only run the tests whose name matches this regular expression" name:"run"`
+	Verbose bool   `usage:"List the tests that passed too" short:"v"`
+	Junit   string `usage:"Write the results as JUnit XML to this file"`
+	KeyFile string `usage:"File of the key used by std.decrypt to decrypt values, see aml encrypt"`
+
+	Coverage       string `usage:"Write a report of the fields, branches, loops and functions of the test files that were evaluated to this file, - for stderr"`
+	CoverageFormat string `usage:"Format of the coverage report (text, html, go)" default:"text"`
+}
+
+func NewTest(aml *AML) *cobra.Command {
+	return cmd.Command(&Test{aml: aml}, cobra.Command{
+		Use:   "test [flags] [PATH...]",
+		Short: "Runs the tests in *_test.acorn files",
+		Long: `Runs the tests in *_test.acorn files
+
+Directories are searched recursively for files ending in _test.acorn, the current
+directory is searched if no PATH is given. Each top-level field of a test file whose
+name starts with test_ is a test, and is evaluated on its own. A test is either a bool
+that must be true or an object with expect and actual fields that must be equal:
+
+	test_add: add(1, 2) == 3
+
+	test_labels: {
+		expect: {app: "web"}
+		actual: labels("web")
+	}`,
+		SilenceErrors: true,
+	})
+}
+
+func (t *Test) Run(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+
+	files, err := amltest.Files(args)
+	if err != nil {
+		return err
+	}
+
+	key, err := readKeyFile(t.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	opts := amltest.Options{
+		Run:           t.RunExpr,
+		DecryptionKey: key,
+	}
+	if t.Coverage != "" {
+		opts.Coverage = eval.NewCoverage()
+	}
+
+	results, err := amltest.Run(cmd.Context(), files, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.Coverage != nil {
+		if err := writeCoverage(t.Coverage, t.CoverageFormat, opts.Coverage); err != nil {
+			return err
+		}
+	}
+
+	if t.Junit != "" {
+		f, err := os.Create(t.Junit)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := amltest.WriteJUnit(f, results); err != nil {
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+
+	if err := amltest.WriteText(cmd.OutOrStdout(), results, t.Verbose); err != nil {
+		return err
+	}
+
+	if len(results) == 0 {
+		return fmt.Errorf("no tests found")
+	} else if failed := amltest.Failed(results); failed > 0 {
+		return fmt.Errorf("%d of %d test(s) failed", failed, len(results))
+	}
+	return nil
+}
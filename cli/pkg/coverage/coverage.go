@@ -0,0 +1,147 @@
+package coverage
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/acorn-io/aml/pkg/eval"
+)
+
+const (
+	Text = "text"
+	HTML = "html"
+	Go   = "go"
+)
+
+var Formats = []string{Text, HTML, Go}
+
+// Write writes a report of the blocks in the given format. The html format reads the source of
+// the files the blocks are in.
+func Write(w io.Writer, format string, blocks []eval.CoverageBlock) error {
+	switch format {
+	case Text:
+		return WriteText(w, blocks)
+	case HTML:
+		return WriteHTML(w, blocks)
+	case Go:
+		return WriteGoCover(w, blocks)
+	}
+	return fmt.Errorf("unknown coverage format %s, must be one of %s", format, strings.Join(Formats, ", "))
+}
+
+// byFile groups the blocks by file, keeping the order of the files
+func byFile(blocks []eval.CoverageBlock) (files []string, grouped map[string][]eval.CoverageBlock) {
+	grouped = map[string][]eval.CoverageBlock{}
+	for _, block := range blocks {
+		if _, ok := grouped[block.Pos.Filename]; !ok {
+			files = append(files, block.Pos.Filename)
+		}
+		grouped[block.Pos.Filename] = append(grouped[block.Pos.Filename], block)
+	}
+	return
+}
+
+func percent(blocks []eval.CoverageBlock) string {
+	if len(blocks) == 0 {
+		return "0.0%"
+	}
+	var covered int
+	for _, block := range blocks {
+		if block.Count > 0 {
+			covered++
+		}
+	}
+	return fmt.Sprintf("%.1f%%", float64(covered)*100/float64(len(blocks)))
+}
+
+// WriteText writes the percentage of blocks covered in each file followed by the blocks that were
+// not evaluated
+func WriteText(w io.Writer, blocks []eval.CoverageBlock) error {
+	buf := &strings.Builder{}
+	files, grouped := byFile(blocks)
+	for _, file := range files {
+		fmt.Fprintf(buf, "%s: %s of %d blocks covered\n", file, percent(grouped[file]), len(grouped[file]))
+		for _, block := range grouped[file] {
+			if block.Count == 0 {
+				fmt.Fprintf(buf, "\t%d:%d %s not covered\n", block.Pos.Line, block.Pos.Column, block.Kind)
+			}
+		}
+	}
+	fmt.Fprintf(buf, "total: %s of %d blocks covered\n", percent(blocks), len(blocks))
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// WriteGoCover writes the blocks in the format of a Go cover profile so that tools that read Go
+// coverage can read them. Each block counts as one statement.
+func WriteGoCover(w io.Writer, blocks []eval.CoverageBlock) error {
+	buf := &strings.Builder{}
+	buf.WriteString("mode: count\n")
+	for _, block := range blocks {
+		fmt.Fprintf(buf, "%s:%d.%d,%d.%d 1 %d\n", block.Pos.Filename,
+			block.Pos.Line, block.Pos.Column, block.End.Line, block.End.Column, block.Count)
+	}
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>AML coverage</title>
+<style>
+body { font-family: sans-serif; }
+pre { font-family: monospace; }
+.covered { background: #c8f0c8; }
+.uncovered { background: #f6c6c6; }
+</style>
+</head>
+<body>
+`
+
+// WriteHTML writes the source of each file with the lines colored green if the innermost block
+// containing the line was evaluated and red if it was not
+func WriteHTML(w io.Writer, blocks []eval.CoverageBlock) error {
+	buf := &strings.Builder{}
+	buf.WriteString(htmlHeader)
+
+	files, grouped := byFile(blocks)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "<h2>%s: %s covered</h2>\n<pre>\n", html.EscapeString(file), percent(grouped[file]))
+		for i, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			text := html.EscapeString(line)
+			if block, ok := innermost(grouped[file], i+1); !ok {
+				buf.WriteString(text)
+			} else if block.Count > 0 {
+				fmt.Fprintf(buf, `<span class="covered" title="%s evaluated %d time(s)">%s</span>`, block.Kind, block.Count, text)
+			} else {
+				fmt.Fprintf(buf, `<span class="uncovered" title="%s not evaluated">%s</span>`, block.Kind, text)
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString("</pre>\n")
+	}
+
+	buf.WriteString("</body>\n</html>\n")
+	_, err := io.WriteString(w, buf.String())
+	return err
+}
+
+// innermost returns the block that contains the line and starts last, blocks are sorted by
+// position
+func innermost(blocks []eval.CoverageBlock, line int) (result eval.CoverageBlock, found bool) {
+	for _, block := range blocks {
+		if block.Pos.Line <= line && line <= block.End.Line {
+			result, found = block, true
+		}
+	}
+	return
+}
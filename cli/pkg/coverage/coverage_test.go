@@ -0,0 +1,49 @@
+package coverage
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/acorn-io/aml"
+	"github.com/acorn-io/aml/pkg/ast"
+	"github.com/acorn-io/aml/pkg/eval"
+	"github.com/acorn-io/aml/pkg/value"
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func evalCoverage(t *testing.T) []eval.CoverageBlock {
+	data, err := os.ReadFile("testdata/input.acorn")
+	require.NoError(t, err)
+
+	var parsed ast.File
+	require.NoError(t, aml.Unmarshal(data, &parsed, aml.DecoderOption{
+		SourceName: "testdata/input.acorn",
+	}))
+
+	cov := eval.NewCoverage()
+	cov.AddFile(&parsed)
+
+	var out value.Value
+	require.NoError(t, aml.Unmarshal(data, &out, aml.DecoderOption{
+		SourceName: "testdata/input.acorn",
+		Context:    eval.WithCoverage(context.Background(), cov),
+	}))
+	return cov.Blocks()
+}
+
+func TestWrite(t *testing.T) {
+	blocks := evalCoverage(t)
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			require.NoError(t, Write(buf, format, blocks))
+			autogold.ExpectFile(t, autogold.Raw(buf.String()))
+		})
+	}
+
+	err := Write(&bytes.Buffer{}, "xml", blocks)
+	autogold.Expect("unknown coverage format xml, must be one of text, html, go").Equal(t, err.Error())
+}
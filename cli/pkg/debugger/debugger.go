@@ -0,0 +1,334 @@
+package debugger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/acorn-io/aml/pkg/eval"
+	"github.com/acorn-io/aml/pkg/parser"
+	"github.com/acorn-io/aml/pkg/value"
+)
+
+// ErrQuit is returned from the evaluation when the quit command is used
+var ErrQuit = errors.New("debugging stopped")
+
+const help = `Commands:
+  break FILE:LINE, b     Pause before evaluating a field or function call on the line, LINE alone uses the current file
+  clear FILE:LINE        Remove a breakpoint
+  breakpoints            List the breakpoints
+  continue, c            Run until the next breakpoint
+  step, s                Pause at the next field or function call, stepping into calls
+  next, n                Pause at the next field or function call that is not nested in the current one
+  out, o                 Pause at the next field or function call after the current one returns
+  stack, bt              Print the call stack
+  vars [PATH]            Print the keys and values of each scope, or only the scopes whose path starts with PATH
+  print EXPR, p          Evaluate the expression in the current scope
+  watch EXPR             Evaluate the expression each time the evaluation pauses
+  unwatch N              Remove the watch expression with index N
+  list, l                Print the source around the current position
+  help, h                Print this help
+  quit, q                Stop the evaluation`
+
+type mode int
+
+const (
+	modeContinue mode = iota
+	modeStep
+	modeNext
+	modeOut
+)
+
+// Breakpoint pauses the evaluation before a field or function call on the line is evaluated
+type Breakpoint struct {
+	File string
+	Line int
+}
+
+func (b Breakpoint) String() string {
+	return fmt.Sprintf("%s:%d", b.File, b.Line)
+}
+
+// ParseBreakpoint parses FILE:LINE, if the file is omitted defaultFile is used
+func ParseBreakpoint(s, defaultFile string) (Breakpoint, error) {
+	file, line := defaultFile, s
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		file, line = s[:i], s[i+1:]
+	}
+	n, err := strconv.Atoi(line)
+	if err != nil || n <= 0 || file == "" {
+		return Breakpoint{}, fmt.Errorf("invalid breakpoint %q, must be FILE:LINE", s)
+	}
+	return Breakpoint{
+		File: file,
+		Line: n,
+	}, nil
+}
+
+func (b Breakpoint) matches(pos eval.Position) bool {
+	if b.Line != pos.Line {
+		return false
+	}
+	if filepath.Clean(b.File) == filepath.Clean(pos.Filename) {
+		return true
+	}
+	return !strings.ContainsRune(b.File, filepath.Separator) && b.File == filepath.Base(pos.Filename)
+}
+
+// Debugger is a line-oriented debugger that reads commands from an input and writes to an
+// output. It implements eval.Debugger and pauses the evaluation at the first frame unless
+// Continue is set.
+type Debugger struct {
+	in  *bufio.Scanner
+	out io.Writer
+
+	mode        mode
+	target      int
+	breakpoints []Breakpoint
+	watches     []string
+	stack       []eval.DebugFrame
+	inspecting  bool
+	sources     map[string][]string
+}
+
+type Options struct {
+	Breakpoints []Breakpoint
+	// Continue runs until the first breakpoint instead of pausing at the first frame
+	Continue bool
+}
+
+func New(in io.Reader, out io.Writer, opts Options) *Debugger {
+	d := &Debugger{
+		in:          bufio.NewScanner(in),
+		out:         out,
+		mode:        modeStep,
+		breakpoints: opts.Breakpoints,
+		sources:     map[string][]string{},
+	}
+	if opts.Continue {
+		d.mode = modeContinue
+	}
+	return d
+}
+
+func (d *Debugger) Enter(frame eval.DebugFrame) error {
+	if d.inspecting {
+		return nil
+	}
+	d.stack = append(d.stack, frame)
+	if !d.shouldPause(frame) {
+		return nil
+	}
+	return d.pause(frame)
+}
+
+func (d *Debugger) Exit(eval.DebugFrame) {
+	if d.inspecting || len(d.stack) == 0 {
+		return
+	}
+	d.stack = d.stack[:len(d.stack)-1]
+}
+
+func (d *Debugger) shouldPause(frame eval.DebugFrame) bool {
+	for _, b := range d.breakpoints {
+		if b.matches(frame.Pos) {
+			return true
+		}
+	}
+	switch d.mode {
+	case modeStep:
+		return true
+	case modeNext:
+		return len(d.stack) <= d.target
+	case modeOut:
+		return len(d.stack) < d.target
+	}
+	return false
+}
+
+func (d *Debugger) pause(frame eval.DebugFrame) error {
+	fmt.Fprintf(d.out, "> %s (%s) depth %d\n", frame.Name, frame.Pos, frame.Depth)
+	d.printLine(frame.Pos, frame.Pos.Line)
+	for i, watch := range d.watches {
+		fmt.Fprintf(d.out, "  watch %d: %s = %s\n", i, watch, d.evaluate(frame, watch))
+	}
+
+	for {
+		fmt.Fprint(d.out, "(aml) ")
+		if !d.in.Scan() {
+			fmt.Fprintln(d.out)
+			if err := d.in.Err(); err != nil {
+				return err
+			}
+			return ErrQuit
+		}
+
+		command, arg, _ := strings.Cut(strings.TrimSpace(d.in.Text()), " ")
+		arg = strings.TrimSpace(arg)
+		switch command {
+		case "":
+		case "continue", "c":
+			d.mode = modeContinue
+			return nil
+		case "step", "s":
+			d.mode = modeStep
+			return nil
+		case "next", "n":
+			d.mode, d.target = modeNext, len(d.stack)
+			return nil
+		case "out", "o":
+			d.mode, d.target = modeOut, len(d.stack)
+			return nil
+		case "quit", "q":
+			return ErrQuit
+		case "break", "b":
+			b, err := ParseBreakpoint(arg, frame.Pos.Filename)
+			if err != nil {
+				fmt.Fprintln(d.out, err)
+				continue
+			}
+			d.breakpoints = append(d.breakpoints, b)
+			fmt.Fprintf(d.out, "breakpoint %d at %s\n", len(d.breakpoints)-1, b)
+		case "clear":
+			d.clear(arg, frame)
+		case "breakpoints":
+			for i, b := range d.breakpoints {
+				fmt.Fprintf(d.out, "%d: %s\n", i, b)
+			}
+		case "stack", "bt":
+			d.printStack()
+		case "vars":
+			d.printVars(frame, arg)
+		case "print", "p":
+			fmt.Fprintln(d.out, d.evaluate(frame, arg))
+		case "watch":
+			if arg == "" {
+				fmt.Fprintln(d.out, "watch requires an expression")
+				continue
+			}
+			d.watches = append(d.watches, arg)
+			fmt.Fprintf(d.out, "watch %d: %s = %s\n", len(d.watches)-1, arg, d.evaluate(frame, arg))
+		case "unwatch":
+			i, err := strconv.Atoi(arg)
+			if err != nil || i < 0 || i >= len(d.watches) {
+				fmt.Fprintf(d.out, "invalid watch %q\n", arg)
+				continue
+			}
+			d.watches = append(d.watches[:i], d.watches[i+1:]...)
+		case "list", "l":
+			for line := frame.Pos.Line - 5; line <= frame.Pos.Line+5; line++ {
+				d.printLine(frame.Pos, line)
+			}
+		case "help", "h":
+			fmt.Fprintln(d.out, help)
+		default:
+			fmt.Fprintf(d.out, "unknown command %q, see help\n", command)
+		}
+	}
+}
+
+func (d *Debugger) clear(arg string, frame eval.DebugFrame) {
+	b, err := ParseBreakpoint(arg, frame.Pos.Filename)
+	if err != nil {
+		fmt.Fprintln(d.out, err)
+		return
+	}
+	for i, existing := range d.breakpoints {
+		if existing == b {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return
+		}
+	}
+	fmt.Fprintf(d.out, "no breakpoint at %s\n", b)
+}
+
+func (d *Debugger) printStack() {
+	for i := len(d.stack) - 1; i >= 0; i-- {
+		frame := d.stack[i]
+		fmt.Fprintf(d.out, "#%d %s (%s) depth %d\n", len(d.stack)-1-i, frame.Name, frame.Pos, frame.Depth)
+	}
+}
+
+// printVars prints the keys and values of each scope of the frame from the innermost scope out
+func (d *Debugger) printVars(frame eval.DebugFrame, prefix string) {
+	for _, level := range eval.ScopeLevels(frame.Scope) {
+		if !strings.HasPrefix(level.Path, prefix) {
+			continue
+		}
+		path := level.Path
+		if path == "" {
+			path = "<root>"
+		}
+		fmt.Fprintf(d.out, "%s (depth %d):\n", path, level.Depth)
+		for _, key := range level.Keys {
+			fmt.Fprintf(d.out, "  %s = %s\n", key, d.lookup(level.Scope, key))
+		}
+	}
+}
+
+func (d *Debugger) lookup(scope eval.Scope, key string) string {
+	d.inspecting = true
+	defer func() { d.inspecting = false }()
+
+	v, ok, err := scope.Get(key)
+	if err != nil {
+		return "error: " + err.Error()
+	} else if !ok {
+		return "(undefined)"
+	}
+	return display(v)
+}
+
+// evaluate returns the value of the expression in the scope of the frame, or the error
+func (d *Debugger) evaluate(frame eval.DebugFrame, expr string) string {
+	d.inspecting = true
+	defer func() { d.inspecting = false }()
+
+	parsed, err := parser.ParseExpr("<expr>", strings.NewReader(expr))
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	e, err := eval.BuildExpr(parsed)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	v, ok, err := e.ToValue(frame.Scope)
+	if err != nil {
+		return "error: " + err.Error()
+	} else if !ok {
+		return "(undefined)"
+	}
+	return display(v)
+}
+
+func display(v value.Value) string {
+	if v.Kind() == value.FuncKind {
+		return "(function)"
+	}
+	return fmt.Sprint(v)
+}
+
+// printLine prints a line of the file of pos, marking the line of pos
+func (d *Debugger) printLine(pos eval.Position, line int) {
+	lines, ok := d.sources[pos.Filename]
+	if !ok {
+		data, err := os.ReadFile(pos.Filename)
+		if err == nil {
+			lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		}
+		d.sources[pos.Filename] = lines
+	}
+	if line < 1 || line > len(lines) {
+		return
+	}
+	marker := " "
+	if line == pos.Line {
+		marker = "=>"
+	}
+	fmt.Fprintf(d.out, "%2s %4d  %s\n", marker, line, lines[line-1])
+}
@@ -0,0 +1,82 @@
+package debugger
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/acorn-io/aml"
+	"github.com/acorn-io/aml/pkg/eval"
+	"github.com/acorn-io/aml/pkg/value"
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func debug(t *testing.T, commands string, opts Options) (string, value.Value, error) {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/input.acorn")
+	require.NoError(t, err)
+
+	out := &strings.Builder{}
+	d := New(strings.NewReader(commands), out, opts)
+
+	var result value.Value
+	err = aml.Unmarshal(data, &result, aml.DecoderOption{
+		SourceName: "testdata/input.acorn",
+		Context:    eval.WithDebugger(context.Background(), d),
+	})
+	return out.String(), result, err
+}
+
+func TestDebugger(t *testing.T) {
+	out, result, err := debug(t, `break 3
+continue
+stack
+vars double
+print args.n * 10
+watch args.n
+continue
+out
+list
+quit
+`, Options{})
+	require.ErrorIs(t, err, ErrQuit)
+	require.Nil(t, result)
+	autogold.ExpectFile(t, autogold.Raw(out))
+}
+
+func TestDebuggerContinue(t *testing.T) {
+	out, result, err := debug(t, "", Options{
+		Breakpoints: []Breakpoint{{File: "input.acorn", Line: 7}},
+		Continue:    true,
+	})
+	require.ErrorIs(t, err, ErrQuit)
+	require.Nil(t, result)
+	autogold.Expect(`> b.c (testdata/input.acorn:7:2) depth 8
+=>    7  	c: a + 1
+(aml) 
+`).Equal(t, out)
+
+	out, result, err = debug(t, "", Options{
+		Breakpoints: []Breakpoint{{File: "other.acorn", Line: 7}},
+		Continue:    true,
+	})
+	require.NoError(t, err)
+	require.Empty(t, out)
+	require.NotNil(t, result)
+}
+
+func TestParseBreakpoint(t *testing.T) {
+	b, err := ParseBreakpoint("dir/file.acorn:12", "main.acorn")
+	require.NoError(t, err)
+	autogold.Expect(Breakpoint{File: "dir/file.acorn", Line: 12}).Equal(t, b)
+
+	b, err = ParseBreakpoint("7", "main.acorn")
+	require.NoError(t, err)
+	autogold.Expect(Breakpoint{File: "main.acorn", Line: 7}).Equal(t, b)
+
+	_, err = ParseBreakpoint("main.acorn:x", "main.acorn")
+	autogold.Expect(`invalid breakpoint "main.acorn:x", must be FILE:LINE`).Equal(t, err.Error())
+}
@@ -0,0 +1,364 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/acorn-io/aml/pkg/ast"
+	"github.com/acorn-io/aml/pkg/value"
+)
+
+type ChangeType string
+
+const (
+	Added   = ChangeType("add")
+	Removed = ChangeType("remove")
+	Changed = ChangeType("replace")
+)
+
+type Options struct {
+	// ArrayKey is the name of a field used to match elements of arrays of objects. If empty, or
+	// not all elements of an array have the field, elements are matched by index.
+	ArrayKey string
+	// Patch will generate changes that can be applied in order as a JSON patch. Arrays matched
+	// by ArrayKey that had elements added, removed or reordered are replaced as a whole.
+	Patch bool
+}
+
+type Change struct {
+	Type ChangeType
+	// Path is the human readable location of the change
+	Path string
+	// Pointer is the JSON pointer (RFC 6901) of the change
+	Pointer string
+	Old     any
+	New     any
+}
+
+// Values compares two values and returns the changes needed to go from old to new. Secrets are
+// compared by their value, the values of the changes have them redacted unless
+// value.RevealSecrets is set.
+func Values(oldValue, newValue value.Value, opts Options) ([]Change, error) {
+	oldNative, _, err := nativeValue(oldValue)
+	if err != nil {
+		return nil, err
+	}
+	newNative, _, err := nativeValue(newValue)
+	if err != nil {
+		return nil, err
+	}
+
+	d := differ{
+		opts: opts,
+	}
+	d.diff(location{}, oldNative, newNative)
+	return d.changes, nil
+}
+
+// secret is the native value of a secret, it is compared by its value and marshals redacted
+type secret struct {
+	value any
+}
+
+func (s secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(value.Redacted)
+}
+
+// nativeValue is the same as value.OrderedNativeValue except that secrets are returned as secret
+// unless value.RevealSecrets is set
+func nativeValue(v value.Value) (any, bool, error) {
+	if value.RevealSecrets || !value.ContainsSecret(v) {
+		return value.OrderedNativeValue(v)
+	}
+
+	switch {
+	case value.IsSecret(v):
+		nv, ok, err := value.OrderedNativeValue(v)
+		return secret{value: nv}, ok, err
+	case v.Kind() == value.ArrayKind:
+		items, err := value.ToValueArray(v)
+		if err != nil {
+			return nil, false, err
+		}
+		result := make([]any, 0, len(items))
+		for _, item := range items {
+			nv, ok, err := nativeValue(item)
+			if err != nil {
+				return nil, false, err
+			} else if ok {
+				result = append(result, nv)
+			}
+		}
+		return result, true, nil
+	default:
+		entries, err := value.Entries(v)
+		if err != nil {
+			return nil, false, err
+		}
+		result := &value.OrderedMap{}
+		for _, entry := range entries {
+			nv, ok, err := nativeValue(entry.Value)
+			if err != nil {
+				return nil, false, err
+			} else if ok {
+				result.Set(entry.Key, nv)
+			}
+		}
+		return result, true, nil
+	}
+}
+
+// reveal returns the value of v if it is a secret
+func reveal(v any) any {
+	if s, ok := v.(secret); ok {
+		return s.value
+	}
+	return v
+}
+
+type location struct {
+	path    string
+	pointer string
+}
+
+func (l location) key(key string) location {
+	path := "." + key
+	if !ast.IsValidLabel(key) {
+		path = "[" + strconv.Quote(key) + "]"
+	}
+	if l.path == "" {
+		path = strings.TrimPrefix(path, ".")
+	}
+	return location{
+		path:    l.path + path,
+		pointer: l.pointer + "/" + strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1"),
+	}
+}
+
+func (l location) index(i int) location {
+	return location{
+		path:    fmt.Sprintf("%s[%d]", l.path, i),
+		pointer: fmt.Sprintf("%s/%d", l.pointer, i),
+	}
+}
+
+func (l location) match(key string, keyValue any, i int) location {
+	return location{
+		path:    fmt.Sprintf("%s[%s=%s]", l.path, key, toJSON(keyValue)),
+		pointer: fmt.Sprintf("%s/%d", l.pointer, i),
+	}
+}
+
+type differ struct {
+	opts    Options
+	changes []Change
+}
+
+func (d *differ) add(t ChangeType, loc location, oldValue, newValue any) {
+	d.changes = append(d.changes, Change{
+		Type:    t,
+		Path:    loc.path,
+		Pointer: loc.pointer,
+		Old:     oldValue,
+		New:     newValue,
+	})
+}
+
+func (d *differ) diff(loc location, oldValue, newValue any) {
+	switch oldTyped := oldValue.(type) {
+	case *value.OrderedMap:
+		if newTyped, ok := newValue.(*value.OrderedMap); ok {
+			d.diffObjects(loc, oldTyped, newTyped)
+			return
+		}
+	case []any:
+		if newTyped, ok := newValue.([]any); ok {
+			d.diffArrays(loc, oldTyped, newTyped)
+			return
+		}
+	}
+
+	if !equal(oldValue, newValue) {
+		d.add(Changed, loc, oldValue, newValue)
+	}
+}
+
+func (d *differ) diffObjects(loc location, oldValue, newValue *value.OrderedMap) {
+	for _, key := range oldValue.Keys() {
+		oldItem, _ := oldValue.Get(key)
+		if newItem, ok := newValue.Get(key); ok {
+			d.diff(loc.key(key), oldItem, newItem)
+		} else {
+			d.add(Removed, loc.key(key), oldItem, nil)
+		}
+	}
+	for _, key := range newValue.Keys() {
+		if _, ok := oldValue.Get(key); !ok {
+			newItem, _ := newValue.Get(key)
+			d.add(Added, loc.key(key), nil, newItem)
+		}
+	}
+}
+
+func (d *differ) diffArrays(loc location, oldValue, newValue []any) {
+	oldKeys, oldOK := d.arrayKeys(oldValue)
+	newKeys, newOK := d.arrayKeys(newValue)
+	if oldOK && newOK {
+		d.diffKeyedArrays(loc, oldValue, newValue, oldKeys, newKeys)
+		return
+	}
+
+	for i := 0; i < len(oldValue) && i < len(newValue); i++ {
+		d.diff(loc.index(i), oldValue[i], newValue[i])
+	}
+	// removals are done from the end so that the indexes of the pointers are valid when applied in order
+	for i := len(oldValue) - 1; i >= len(newValue); i-- {
+		d.add(Removed, loc.index(i), oldValue[i], nil)
+	}
+	for i := len(oldValue); i < len(newValue); i++ {
+		d.add(Added, loc.index(i), nil, newValue[i])
+	}
+}
+
+func (d *differ) diffKeyedArrays(loc location, oldValue, newValue []any, oldKeys, newKeys []string) {
+	oldIndex := map[string]int{}
+	for i, key := range oldKeys {
+		oldIndex[key] = i
+	}
+	newIndex := map[string]int{}
+	for i, key := range newKeys {
+		newIndex[key] = i
+	}
+
+	if d.opts.Patch && strings.Join(oldKeys, "\x00") != strings.Join(newKeys, "\x00") {
+		d.add(Changed, loc, oldValue, newValue)
+		return
+	}
+
+	for i, key := range oldKeys {
+		keyValue, _ := oldValue[i].(*value.OrderedMap).Get(d.opts.ArrayKey)
+		if j, ok := newIndex[key]; ok {
+			d.diff(loc.match(d.opts.ArrayKey, keyValue, j), oldValue[i], newValue[j])
+		} else {
+			d.add(Removed, loc.match(d.opts.ArrayKey, keyValue, i), oldValue[i], nil)
+		}
+	}
+	for j, key := range newKeys {
+		if _, ok := oldIndex[key]; !ok {
+			keyValue, _ := newValue[j].(*value.OrderedMap).Get(d.opts.ArrayKey)
+			d.add(Added, loc.match(d.opts.ArrayKey, keyValue, j), nil, newValue[j])
+		}
+	}
+}
+
+// arrayKeys returns the value of the array key field of each element. False is returned if the
+// elements can not be matched by key.
+func (d *differ) arrayKeys(items []any) (result []string, _ bool) {
+	if d.opts.ArrayKey == "" {
+		return nil, false
+	}
+	seen := map[string]bool{}
+	for _, item := range items {
+		obj, ok := item.(*value.OrderedMap)
+		if !ok {
+			return nil, false
+		}
+		keyValue, ok := obj.Get(d.opts.ArrayKey)
+		if !ok {
+			return nil, false
+		}
+		key := toJSON(reveal(keyValue))
+		if seen[key] {
+			return nil, false
+		}
+		seen[key] = true
+		result = append(result, key)
+	}
+	return result, true
+}
+
+func equal(left, right any) bool {
+	left, right = reveal(left), reveal(right)
+	leftNumber, leftOK := left.(value.Number)
+	rightNumber, rightOK := right.(value.Number)
+	if leftOK && rightOK {
+		b, err := value.Eq(leftNumber, rightNumber)
+		if err != nil {
+			return false
+		}
+		eq, err := value.ToBool(b)
+		return err == nil && eq
+	}
+	return toJSON(left) == toJSON(right)
+}
+
+func toJSON(v any) string {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// WriteText writes the changes one per line, prefixed with + for added, - for removed and ~ for
+// changed values.
+func WriteText(w io.Writer, changes []Change) error {
+	buf := &bytes.Buffer{}
+	for _, change := range changes {
+		path := change.Path
+		if path == "" {
+			path = "."
+		}
+		switch change.Type {
+		case Added:
+			fmt.Fprintf(buf, "+ %s: %s\n", path, toJSON(change.New))
+		case Removed:
+			fmt.Fprintf(buf, "- %s: %s\n", path, toJSON(change.Old))
+		case Changed:
+			fmt.Fprintf(buf, "~ %s: %s -> %s\n", path, toJSON(change.Old), toJSON(change.New))
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+type patchOperation struct {
+	Op    ChangeType `json:"op"`
+	Path  string     `json:"path"`
+	Value any        `json:"value"`
+}
+
+type removeOperation struct {
+	Op   ChangeType `json:"op"`
+	Path string     `json:"path"`
+}
+
+// WriteJSONPatch writes the changes as a JSON patch (RFC 6902). The changes should be generated
+// with the Patch option set.
+func WriteJSONPatch(w io.Writer, changes []Change) error {
+	ops := make([]any, 0, len(changes))
+	for _, change := range changes {
+		if change.Type == Removed {
+			ops = append(ops, removeOperation{
+				Op:   change.Type,
+				Path: change.Pointer,
+			})
+		} else {
+			ops = append(ops, patchOperation{
+				Op:    change.Type,
+				Path:  change.Pointer,
+				Value: change.New,
+			})
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(ops)
+}
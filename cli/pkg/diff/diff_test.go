@@ -0,0 +1,77 @@
+package diff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/acorn-io/aml"
+	"github.com/acorn-io/aml/pkg/value"
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	oldDocument = `
+containers: [{name: "web", image: "nginx:1"}, {name: "db", image: "mysql"}]
+replicas: 1
+labels: {a: "x", "app/name": "web"}
+`
+	newDocument = `
+containers: [{name: "cache", image: "redis"}, {name: "web", image: "nginx:2"}]
+replicas: 1.0
+labels: {"app/name": "web2", b: null}
+`
+)
+
+func diff(t *testing.T, opts Options) []Change {
+	var oldValue, newValue value.Value
+	require.NoError(t, aml.Unmarshal([]byte(oldDocument), &oldValue))
+	require.NoError(t, aml.Unmarshal([]byte(newDocument), &newValue))
+
+	changes, err := Values(oldValue, newValue, opts)
+	require.NoError(t, err)
+	return changes
+}
+
+func TestWriteText(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteText(buf, diff(t, Options{})))
+	autogold.Expect(`~ containers[0].name: "web" -> "cache"
+~ containers[0].image: "nginx:1" -> "redis"
+~ containers[1].name: "db" -> "web"
+~ containers[1].image: "mysql" -> "nginx:2"
+- labels.a: "x"
+~ labels["app/name"]: "web" -> "web2"
++ labels.b: null
+`).Equal(t, buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteText(buf, diff(t, Options{ArrayKey: "name"})))
+	autogold.Expect(`~ containers[name="web"].image: "nginx:1" -> "nginx:2"
+- containers[name="db"]: {"name":"db","image":"mysql"}
++ containers[name="cache"]: {"name":"cache","image":"redis"}
+- labels.a: "x"
+~ labels["app/name"]: "web" -> "web2"
++ labels.b: null
+`).Equal(t, buf.String())
+}
+
+func TestWriteJSONPatch(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteJSONPatch(buf, diff(t, Options{ArrayKey: "name", Patch: true})))
+	autogold.ExpectFile(t, autogold.Raw(buf.String()))
+}
+
+func TestWriteTextSecrets(t *testing.T) {
+	var oldValue, newValue value.Value
+	require.NoError(t, aml.Unmarshal([]byte(`pw: std.secret("a"), user: std.secret("admin")`), &oldValue))
+	require.NoError(t, aml.Unmarshal([]byte(`pw: std.secret("b"), user: std.secret("admin")`), &newValue))
+
+	changes, err := Values(oldValue, newValue, Options{})
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteText(buf, changes))
+	autogold.Expect(`~ pw: "(redacted)" -> "(redacted)"
+`).Equal(t, buf.String())
+}
@@ -0,0 +1,362 @@
+package doc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/acorn-io/aml/pkg/schema"
+)
+
+const (
+	Markdown = "markdown"
+	HTML     = "html"
+)
+
+var Formats = []string{Markdown, HTML}
+
+type Options struct {
+	// Title is the title of the document, usually the name of the file
+	Title string
+	// Format is markdown or html, defaults to markdown
+	Format string
+}
+
+// Write writes the documentation of the args and profiles in file and the output schema in
+// summary. Summary may be nil, in which case the output section is omitted.
+func Write(w io.Writer, file *schema.File, summary *schema.Summary, opts Options) error {
+	var r renderer
+	switch opts.Format {
+	case Markdown, "":
+		r = &markdownRenderer{}
+	case HTML:
+		r = &htmlRenderer{}
+	default:
+		return fmt.Errorf("invalid doc format %q, must be one of %s", opts.Format, strings.Join(Formats, ", "))
+	}
+
+	r.begin(opts.Title)
+	writeArgs(r, file)
+	writeProfiles(r, file)
+	if summary != nil {
+		writeOutput(r, summary)
+	}
+	r.end()
+
+	_, err := io.WriteString(w, r.String())
+	return err
+}
+
+// Placeholders returns a value for each required arg so that the file can be evaluated to
+// describe its output. The value is the first value an == constraint of the arg or its alternates
+// allows, otherwise a value of the kind of the arg that satisfies its number bounds.
+func Placeholders(args schema.Object) map[string]any {
+	result := map[string]any{}
+	for _, field := range args.Fields {
+		if field.Match || field.Optional || field.Type.DefaultValue() != nil {
+			continue
+		}
+		result[field.Name] = placeholder(field.Type)
+	}
+	return result
+}
+
+func placeholder(fieldType schema.FieldType) any {
+	for alt := &fieldType; alt != nil; alt = alt.Alternate {
+		for _, constraint := range alt.Constraint {
+			if constraint.Op == "==" && constraint.Left == nil && constraint.Right != nil {
+				return constraint.Right
+			}
+		}
+	}
+
+	switch fieldType.Kind {
+	case schema.StringKind:
+		return ""
+	case schema.BoolKind:
+		return false
+	case schema.ArrayKind:
+		return []any{}
+	case schema.ObjectKind:
+		result := map[string]any{}
+		if fieldType.Object != nil {
+			result = Placeholders(*fieldType.Object)
+		}
+		return result
+	case schema.NumberKind:
+		var n float64
+		for _, constraint := range fieldType.Constraint {
+			bound, err := strconv.ParseFloat(fmt.Sprint(constraint.Right), 64)
+			if err != nil || constraint.Left != nil {
+				continue
+			}
+			switch constraint.Op {
+			case ">":
+				n = max(n, bound+1)
+			case ">=":
+				n = max(n, bound)
+			case "<":
+				n = min(n, bound-1)
+			case "<=":
+				n = min(n, bound)
+			}
+		}
+		return n
+	}
+	return nil
+}
+
+// span is a piece of inline content of a paragraph or a table cell
+type span struct {
+	text string
+	code bool
+	href string
+}
+
+type cell []span
+
+type row []cell
+
+func text(s string) span {
+	return span{text: s}
+}
+
+func code(s string) span {
+	return span{text: s, code: true}
+}
+
+type renderer interface {
+	begin(title string)
+	heading(level int, anchor, text string)
+	paragraph(content ...span)
+	table(headers []string, rows []row)
+	end()
+	String() string
+}
+
+func writeArgs(r renderer, file *schema.File) {
+	r.heading(2, "arguments", "Arguments")
+	if file.Args.Description != "" {
+		r.paragraph(text(file.Args.Description))
+	}
+
+	var rows []row
+	for _, field := range flatten("", file.Args.Fields) {
+		rows = append(rows, row{
+			fieldName(field),
+			typeName(field.Type),
+			defaultValue(field.Type),
+			constraints(field.Type),
+			cell{text(field.Description)},
+		})
+	}
+	if len(rows) == 0 {
+		r.paragraph(text("This file has no arguments."))
+		return
+	}
+	r.table([]string{"Name", "Type", "Default", "Constraints", "Description"}, rows)
+}
+
+func writeProfiles(r renderer, file *schema.File) {
+	if len(file.ProfileNames) == 0 {
+		return
+	}
+
+	r.heading(2, "profiles", "Profiles")
+	for _, profile := range file.ProfileNames {
+		r.heading(3, anchor("profile", profile.Name), profile.Name)
+		if profile.Description != "" {
+			r.paragraph(text(profile.Description))
+		}
+
+		var rows []row
+		for _, field := range flatten("", profileFields(file.Profiles, profile.Name)) {
+			rows = append(rows, row{fieldName(field), defaultValue(field.Type)})
+		}
+		if len(rows) == 0 {
+			r.paragraph(text("This profile does not change any arguments."))
+			continue
+		}
+		r.table([]string{"Argument", "Value"}, rows)
+	}
+}
+
+func profileFields(profiles schema.Object, name string) []schema.Field {
+	for _, field := range profiles.Fields {
+		if field.Name == name && field.Type.Object != nil {
+			return field.Type.Object.Fields
+		}
+	}
+	return nil
+}
+
+func writeOutput(r renderer, summary *schema.Summary) {
+	r.heading(2, "output", "Output")
+	writeFields(r, summary.Fields)
+
+	paths := make([]string, 0, len(summary.Types))
+	for path := range summary.Types {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		fieldType := summary.Types[path]
+		r.heading(3, anchor("type", path), path)
+		if fieldType.Object != nil {
+			if fieldType.Object.Description != "" {
+				r.paragraph(text(fieldType.Object.Description))
+			}
+			writeFields(r, fieldType.Object.Fields)
+		}
+	}
+}
+
+func writeFields(r renderer, fields []schema.Field) {
+	var rows []row
+	for _, field := range fields {
+		rows = append(rows, row{
+			fieldName(field),
+			typeName(field.Type),
+			constraints(field.Type),
+			cell{text(field.Description)},
+		})
+	}
+	if len(rows) == 0 {
+		r.paragraph(text("No fields are defined."))
+		return
+	}
+	r.table([]string{"Name", "Type", "Constraints", "Description"}, rows)
+}
+
+// flatten replaces fields that are inline objects with their fields using dotted names
+func flatten(prefix string, fields []schema.Field) (result []schema.Field) {
+	for _, field := range fields {
+		if prefix != "" {
+			field.Name = prefix + "." + field.Name
+		}
+		if obj := field.Type.Object; obj != nil && !obj.Reference && len(obj.Fields) > 0 && !field.Match {
+			result = append(result, flatten(field.Name, obj.Fields)...)
+			continue
+		}
+		result = append(result, field)
+	}
+	return
+}
+
+func fieldName(field schema.Field) cell {
+	result := cell{code(field.Name)}
+	if field.Match {
+		result = append(result, text(" (pattern)"))
+	}
+	if field.Optional {
+		result = append(result, text(" (optional)"))
+	}
+	return result
+}
+
+// typeName describes the type and its alternates, alternates that are described the same are
+// only included once
+func typeName(fieldType schema.FieldType) (result cell) {
+	seen := map[string]bool{}
+	for alt := &fieldType; alt != nil; alt = alt.Alternate {
+		name := singleTypeName(*alt)
+		key := fmt.Sprint(name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if len(result) > 0 {
+			result = append(result, text(" or "))
+		}
+		result = append(result, name...)
+	}
+	if fieldType.Sensitive {
+		result = append(result, text(" (sensitive)"))
+	}
+	return result
+}
+
+func singleTypeName(fieldType schema.FieldType) cell {
+	switch {
+	case fieldType.Object != nil && fieldType.Object.Reference:
+		return cell{{
+			text: fieldType.Object.Path,
+			href: "#" + anchor("type", fieldType.Object.Path),
+		}}
+	case fieldType.Kind == schema.ArrayKind && fieldType.Array != nil:
+		items := typeName(fieldType.Array.Items)
+		if slices.Contains(items, text(" or ")) {
+			items = append(append(cell{text("(")}, items...), text(")"))
+		}
+		return append(cell{text("array of ")}, items...)
+	}
+	return cell{text(string(fieldType.Kind))}
+}
+
+func defaultValue(fieldType schema.FieldType) cell {
+	def := fieldType.DefaultValue()
+	if def == nil {
+		return nil
+	}
+	return cell{code(toJSON(def))}
+}
+
+// constraints describes the constraints of the type and its alternates joined with or. If the
+// type or one of its alternates has no constraints there is nothing to describe.
+func constraints(fieldType schema.FieldType) (result cell) {
+	seen := map[string]bool{}
+	for alt := &fieldType; alt != nil; alt = alt.Alternate {
+		c := singleConstraints(*alt)
+		if len(c) == 0 {
+			return nil
+		}
+		key := fmt.Sprint(c)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if len(result) > 0 {
+			result = append(result, text(" or "))
+		}
+		result = append(result, c...)
+	}
+	return result
+}
+
+func singleConstraints(fieldType schema.FieldType) (result cell) {
+	for _, constraint := range fieldType.Constraint {
+		if len(result) > 0 {
+			result = append(result, text(", "))
+		}
+		if constraint.Description != "" {
+			result = append(result, text(constraint.Description))
+			continue
+		}
+		expr := constraint.Op + " " + toJSON(constraint.Right)
+		if constraint.Left != nil {
+			expr = toJSON(constraint.Left) + " " + expr
+		}
+		result = append(result, code(expr))
+	}
+	return result
+}
+
+func toJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+var nonAnchor = regexp.MustCompile(`[^a-z0-9]+`)
+
+func anchor(kind, name string) string {
+	return kind + "-" + strings.Trim(nonAnchor.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
@@ -0,0 +1,63 @@
+package doc
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/acorn-io/aml"
+	"github.com/acorn-io/aml/pkg/schema"
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	data, err := os.ReadFile("testdata/Acornfile")
+	require.NoError(t, err)
+
+	file := &schema.File{}
+	require.NoError(t, aml.Unmarshal(data, file))
+
+	summary := &schema.Summary{}
+	require.NoError(t, aml.Unmarshal(data, summary))
+
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			require.NoError(t, Write(buf, file, summary, Options{
+				Title:  "Acornfile",
+				Format: format,
+			}))
+			autogold.ExpectFile(t, autogold.Raw(buf.String()))
+		})
+	}
+}
+
+func TestWriteNoArgs(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf, &schema.File{}, nil, Options{}))
+	autogold.Expect(`## <a id="arguments"></a>Arguments
+
+This file has no arguments.
+
+`).Equal(t, buf.String())
+}
+
+func TestWriteRequired(t *testing.T) {
+	data, err := os.ReadFile("testdata/Required")
+	require.NoError(t, err)
+
+	file := &schema.File{}
+	require.NoError(t, aml.Unmarshal(data, file))
+
+	summary := &schema.Summary{}
+	require.NoError(t, aml.Unmarshal(data, summary, aml.DecoderOption{
+		Args: Placeholders(file.Args),
+	}))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf, file, summary, Options{
+		Title: "Required",
+	}))
+	autogold.ExpectFile(t, autogold.Raw(buf.String()))
+}
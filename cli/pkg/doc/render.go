@@ -0,0 +1,133 @@
+package doc
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+type markdownRenderer struct {
+	strings.Builder
+}
+
+func (m *markdownRenderer) begin(title string) {
+	if title != "" {
+		fmt.Fprintf(m, "# %s\n\n", markdownEscape(title))
+	}
+}
+
+func (m *markdownRenderer) heading(level int, anchor, text string) {
+	fmt.Fprintf(m, "%s <a id=%q></a>%s\n\n", strings.Repeat("#", level), anchor, markdownEscape(text))
+}
+
+func (m *markdownRenderer) paragraph(content ...span) {
+	m.WriteString(markdownSpans(content))
+	m.WriteString("\n\n")
+}
+
+func (m *markdownRenderer) table(headers []string, rows []row) {
+	m.WriteString("|")
+	for _, header := range headers {
+		fmt.Fprintf(m, " %s |", header)
+	}
+	m.WriteString("\n|")
+	for range headers {
+		m.WriteString(" --- |")
+	}
+	m.WriteString("\n")
+
+	for _, r := range rows {
+		m.WriteString("|")
+		for _, c := range r {
+			text := strings.ReplaceAll(markdownSpans(c), "|", `\|`)
+			text = strings.ReplaceAll(text, "\n", "<br>")
+			fmt.Fprintf(m, " %s |", text)
+		}
+		m.WriteString("\n")
+	}
+	m.WriteString("\n")
+}
+
+func (m *markdownRenderer) end() {
+}
+
+var markdownSpecial = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`)
+
+func markdownEscape(s string) string {
+	return markdownSpecial.Replace(s)
+}
+
+func markdownSpans(spans []span) string {
+	buf := &strings.Builder{}
+	for _, s := range spans {
+		text := markdownEscape(s.text)
+		if s.code {
+			fence := "`"
+			for strings.Contains(s.text, fence) {
+				fence += "`"
+			}
+			text = fence + s.text + fence
+		}
+		if s.href != "" {
+			text = "[" + text + "](" + s.href + ")"
+		}
+		buf.WriteString(text)
+	}
+	return buf.String()
+}
+
+type htmlRenderer struct {
+	strings.Builder
+}
+
+func (h *htmlRenderer) begin(title string) {
+	h.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(h, "<title>%s</title>\n", html.EscapeString(title))
+	h.WriteString("</head>\n<body>\n")
+	if title != "" {
+		fmt.Fprintf(h, "<h1>%s</h1>\n", html.EscapeString(title))
+	}
+}
+
+func (h *htmlRenderer) heading(level int, anchor, text string) {
+	fmt.Fprintf(h, "<h%d id=\"%s\">%s</h%d>\n", level, html.EscapeString(anchor), html.EscapeString(text), level)
+}
+
+func (h *htmlRenderer) paragraph(content ...span) {
+	fmt.Fprintf(h, "<p>%s</p>\n", htmlSpans(content))
+}
+
+func (h *htmlRenderer) table(headers []string, rows []row) {
+	h.WriteString("<table>\n<thead>\n<tr>")
+	for _, header := range headers {
+		fmt.Fprintf(h, "<th>%s</th>", html.EscapeString(header))
+	}
+	h.WriteString("</tr>\n</thead>\n<tbody>\n")
+	for _, r := range rows {
+		h.WriteString("<tr>")
+		for _, c := range r {
+			fmt.Fprintf(h, "<td>%s</td>", htmlSpans(c))
+		}
+		h.WriteString("</tr>\n")
+	}
+	h.WriteString("</tbody>\n</table>\n")
+}
+
+func (h *htmlRenderer) end() {
+	h.WriteString("</body>\n</html>\n")
+}
+
+func htmlSpans(spans []span) string {
+	buf := &strings.Builder{}
+	for _, s := range spans {
+		text := strings.ReplaceAll(html.EscapeString(s.text), "\n", "<br>")
+		if s.code {
+			text = "<code>" + text + "</code>"
+		}
+		if s.href != "" {
+			text = "<a href=\"" + html.EscapeString(s.href) + "\">" + text + "</a>"
+		}
+		buf.WriteString(text)
+	}
+	return buf.String()
+}
@@ -1,51 +1,167 @@
 package flagargs
 
 import (
+	"encoding/csv"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/acorn-io/aml"
 	"github.com/acorn-io/aml/cli/pkg/amlreadhelper"
+	"github.com/acorn-io/aml/pkg/eval"
 	"github.com/acorn-io/aml/pkg/schema"
 	"github.com/acorn-io/aml/pkg/value"
 	"github.com/spf13/pflag"
 )
 
+// DefaultEnvPrefix is the prefix of the environment variables that args are read from, for example
+// AML_ARG_replicas=3 sets the replicas arg and AML_ARG_DB__HOST=db sets the nested db.host arg.
+const DefaultEnvPrefix = "AML_ARG_"
+
+// Options control where args are read from. Values are taken from the following sources, each
+// source overriding the values of the sources before it:
+//
+//  1. the defaults of the args in the file
+//  2. the selected profiles, later profiles override earlier ones
+//  3. the args files, in order, a later file replaces the whole value of a top level arg set by
+//     an earlier one instead of merging with it
+//  4. environment variables named EnvPrefix followed by the name of the arg, with the dots of
+//     nested args written as __ and the case ignored
+//  5. command line flags
+type Options struct {
+	// ArgsFiles are read in order, missing files are ignored unless RequireArgsFiles is set
+	ArgsFiles []string
+	// RequireArgsFiles makes an args file that does not exist an error, it is set for the args
+	// files given by the user as opposed to a default args file
+	RequireArgsFiles bool
+	// EnvPrefix is the prefix of the environment variables to read args from. If empty args are
+	// not read from the environment.
+	EnvPrefix string
+	// Environ is the environment in the form of os.Environ(), if nil os.Environ() is used
+	Environ []string
+}
+
 type Flags struct {
-	FlagSet    *pflag.FlagSet
-	fieldFlags map[string]fieldFlag
-	profile    *[]string
-	Usage      func()
-	argsFile   string
+	FlagSet      *pflag.FlagSet
+	fieldFlags   map[string]fieldFlag
+	profile      *[]string
+	profileNames schema.Names
+	Usage        func()
+	argsFiles    []string
+	requireFiles bool
+	envPrefix    string
+	environ      []string
+	defaults     map[string]any
+	profiles     map[string]map[string]any
+	sources      map[string]string
+	args         []schema.Field
+	argsSchema   value.Value
+}
+
+// EffectiveArg is the value of an arg after parsing and the source it was taken from
+type EffectiveArg struct {
+	Name   string `json:"name"`
+	Value  any    `json:"value"`
+	Source string `json:"source"`
 }
 
 type fieldFlag struct {
-	Field       schema.Field
+	Field schema.Field
+	// Path is the path of the value the flag sets, the flag for db.host has the path [db host]
+	Path        []string
 	String      *string
 	StringSlice *[]string
 	Bool        *bool
+	// Map is set for objects with match fields, each value is key=value or an @ value
+	Map *[]string
 }
 
-func ParseArgs(argsFile, acornFile string, args []string) (map[string]any, []string, error) {
-	f, err := os.Open(acornFile)
+// ParseArgs returns the args and profiles for the file from the sources in opts and the command
+// line flags in args.
+func ParseArgs(acornFile string, args []string, opts Options) (map[string]any, []string, error) {
+	flags, err := Load(acornFile, opts)
 	if err != nil {
 		return nil, nil, err
 	}
+	return flags.Parse(args)
+}
+
+// Load returns the flags for the args and profiles of the file
+func Load(acornFile string, opts Options) (*Flags, error) {
+	f, err := os.Open(acornFile)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
 
-	var file schema.File
+	var file eval.File
 	if err := aml.NewDecoder(f).Decode(&file); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	flags := New(argsFile, filepath.Base(acornFile), file.ProfileNames, file.Args.Fields)
-	return flags.Parse(args)
+	fun, ok, err := file.ToFunction(eval.Builtin)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return withOptions(New(opts.ArgsFiles, filepath.Base(acornFile), nil, nil), opts), nil
+	}
+	return FromFunction(filepath.Base(acornFile), fun.(*eval.Function), opts)
 }
 
-func New(argsFile, filename string, profiles schema.Names, args []schema.Field) *Flags {
+// FromFunction returns the flags for the args and profiles of a function, name is the name of
+// the flag set used in errors and help.
+func FromFunction(name string, fun *eval.Function, opts Options) (*Flags, error) {
+	args, err := value.DescribeObject(value.SchemaContext{}, fun.ArgsSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles, err := value.DescribeObject(value.SchemaContext{}, fun.ProfilesSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := withOptions(New(opts.ArgsFiles, name, fun.ProfileNames.Describe(), args.Fields), opts)
+	flags.profiles = fieldDefaults(profiles.Fields)
+	flags.argsSchema = fun.ArgsSchema
+	return flags, nil
+}
+
+func withOptions(flags *Flags, opts Options) *Flags {
+	flags.requireFiles = opts.RequireArgsFiles
+	flags.envPrefix = opts.EnvPrefix
+	flags.environ = opts.Environ
+	if flags.environ == nil {
+		flags.environ = os.Environ()
+	}
+	return flags
+}
+
+func fieldDefaults(fields []schema.Field) map[string]map[string]any {
+	result := map[string]map[string]any{}
+	for _, field := range fields {
+		if field.Type.Object == nil {
+			continue
+		}
+		values := map[string]any{}
+		for _, profileField := range field.Type.Object.Fields {
+			if profileField.Type.Default != nil {
+				values[profileField.Name] = profileField.Type.Default
+			}
+		}
+		result[field.Name] = values
+	}
+	return result
+}
+
+func New(argsFiles []string, filename string, profiles schema.Names, args []schema.Field) *Flags {
 	var (
 		flagSet    = pflag.NewFlagSet(filename, pflag.ContinueOnError)
 		fieldFlags = map[string]fieldFlag{}
+		defaults   = map[string]any{}
 		profile    *[]string
 	)
 
@@ -66,30 +182,150 @@ func New(argsFile, filename string, profiles schema.Names, args []schema.Field)
 	profile = flagSet.StringSlice("profile", nil, desc.String())
 
 	for _, field := range args {
-		flag := fieldFlag{
-			Field: field,
-		}
 		if profile != nil && field.Name == "profile" {
 			continue
 		}
-		if field.Type.Kind == schema.BoolKind {
-			flag.Bool = flagSet.Bool(field.Name, false, field.Description)
-		} else if field.Type.Kind == schema.ArrayKind {
-			flag.StringSlice = flagSet.StringSlice(field.Name, nil, field.Description)
-		} else {
-			flag.String = flagSet.String(field.Name, "", field.Description)
+		if field.Type.Default != nil {
+			defaults[field.Name] = field.Type.Default
 		}
-		fieldFlags[field.Name] = flag
+		addFieldFlags(flagSet, fieldFlags, nil, field)
 	}
 
 	return &Flags{
-		fieldFlags: fieldFlags,
-		profile:    profile,
-		FlagSet:    flagSet,
-		argsFile:   argsFile,
+		fieldFlags:   fieldFlags,
+		profile:      profile,
+		profileNames: profiles,
+		FlagSet:      flagSet,
+		argsFiles:    argsFiles,
+		defaults:     defaults,
+		sources:      map[string]string{},
+		args:         args,
 	}
 }
 
+// addFieldFlags adds the flag for the field and, if the field is an object, a flag for each of its
+// named fields using a dotted name, for example --db.host.
+func addFieldFlags(flagSet *pflag.FlagSet, fieldFlags map[string]fieldFlag, path []string, field schema.Field) {
+	path = append(path[:len(path):len(path)], field.Name)
+	name := strings.Join(path, ".")
+	flag := fieldFlag{
+		Field: field,
+		Path:  path,
+	}
+
+	switch {
+	case field.Type.Kind == schema.BoolKind:
+		flag.Bool = flagSet.Bool(name, false, field.Description)
+	case field.Type.Kind == schema.ArrayKind:
+		flag.StringSlice = flagSet.StringSlice(name, nil, field.Description)
+	case mapValueKind(field.Type) != "":
+		usage := "key=value, can be repeated"
+		if field.Description != "" {
+			usage = field.Description + " (" + usage + ")"
+		}
+		flag.Map = flagSet.StringArray(name, nil, usage)
+	default:
+		flag.String = flagSet.String(name, "", field.Description)
+	}
+	fieldFlags[name] = flag
+
+	if field.Type.Kind != schema.ObjectKind || field.Type.Object == nil {
+		return
+	}
+	for _, child := range field.Type.Object.Fields {
+		if !child.Match {
+			addFieldFlags(flagSet, fieldFlags, path, child)
+		}
+	}
+}
+
+// mapValueKind returns the kind of the values of an object with a match field of a string,
+// number or bool kind. An empty kind is returned for all other types.
+func mapValueKind(fieldType schema.FieldType) schema.Kind {
+	if fieldType.Kind != schema.ObjectKind || fieldType.Object == nil {
+		return ""
+	}
+	for _, field := range fieldType.Object.Fields {
+		if !field.Match {
+			continue
+		}
+		switch field.Type.Kind {
+		case schema.StringKind, schema.NumberKind, schema.BoolKind:
+			return field.Type.Kind
+		}
+	}
+	return ""
+}
+
+// parseMap parses key=value pairs, an @ value is parsed as an object and merged with the pairs
+func parseMap(values []string, kind schema.Kind) (map[string]any, error) {
+	result := map[string]any{}
+	for _, v := range values {
+		if strings.HasPrefix(v, "@") {
+			obj, err := parseValue(v, false)
+			if err != nil {
+				return nil, err
+			}
+			for key, val := range obj.(map[string]any) {
+				result[key] = val
+			}
+			continue
+		}
+
+		key, raw, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid value %q, must be key=value", v)
+		}
+		var (
+			val any
+			err error
+		)
+		if kind == schema.BoolKind {
+			val, err = strconv.ParseBool(raw)
+		} else {
+			val, err = parseValue(raw, kind == schema.NumberKind)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result[key] = val
+	}
+	return result, nil
+}
+
+// setPath sets the value at path in result, creating or replacing the objects along the path.
+// If merge is true and both the value and the existing value are objects their keys are merged.
+func setPath(result map[string]any, path []string, val any, merge bool) {
+	for _, key := range path[:len(path)-1] {
+		child, ok := result[key].(map[string]any)
+		if !ok {
+			child = map[string]any{}
+			result[key] = child
+		}
+		result = child
+	}
+
+	key := path[len(path)-1]
+	existing, existingOK := result[key].(map[string]any)
+	obj, ok := val.(map[string]any)
+	if merge && ok && existingOK {
+		for k, v := range obj {
+			existing[k] = v
+		}
+		return
+	}
+	result[key] = val
+}
+
+// setSource records the source of the top level arg of path. If a nested value is set or the
+// value was merged the source is added to the sources of the other values of the arg.
+func (f *Flags) setSource(path []string, source string, merged bool) {
+	if existing := f.sources[path[0]]; existing != "" && (len(path) > 1 || merged) && existing != source {
+		source = existing + ", " + source
+	}
+	f.sources[path[0]] = source
+}
+
 func parseValue(v string, isNumber bool) (any, error) {
 	if !strings.HasPrefix(v, "@") {
 		if isNumber {
@@ -110,28 +346,125 @@ func parseValue(v string, isNumber bool) (any, error) {
 	return data, amlreadhelper.UnmarshalFile(v, &data)
 }
 
-func (f *Flags) readArgsFile() (map[string]any, error) {
-	result := map[string]any{}
+func (f *Flags) readArgsFiles(result map[string]any) error {
+	for _, argsFile := range f.argsFiles {
+		if argsFile == "" {
+			continue
+		}
+
+		input, err := os.Open(argsFile)
+		if os.IsNotExist(err) && !f.requireFiles {
+			continue
+		} else if err != nil {
+			return err
+		}
 
-	if f.argsFile == "" {
-		return result, nil
+		values := map[string]any{}
+		err = aml.NewDecoder(input, aml.DecoderOption{
+			SourceName: argsFile,
+		}).Decode(&values)
+		input.Close()
+		if err != nil {
+			return err
+		}
+
+		for name, value := range values {
+			result[name] = value
+			f.sources[name] = "args file " + argsFile
+		}
 	}
 
-	input, err := os.Open(f.argsFile)
-	if os.IsNotExist(err) {
-		return result, nil
+	return nil
+}
+
+// readEnv reads the args that have a matching environment variable. Variables that have the
+// prefix but do not match an arg are ignored.
+func (f *Flags) readEnv(result map[string]any) error {
+	if f.envPrefix == "" {
+		return nil
 	}
 
-	if err := aml.NewDecoder(input).Decode(result); err != nil {
-		return nil, err
+	for _, env := range f.environ {
+		k, v, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(k, f.envPrefix) {
+			continue
+		}
+		field, ok := f.envField(strings.TrimPrefix(k, f.envPrefix))
+		if !ok {
+			continue
+		}
+
+		val, err := parseEnvValue(field.Field, v)
+		if err != nil {
+			return fmt.Errorf("invalid value for environment variable %s: %w", k, err)
+		}
+		setPath(result, field.Path, val, field.Map != nil)
+		f.setSource(field.Path, "environment variable "+k, field.Map != nil)
 	}
 
-	return result, nil
+	return nil
+}
+
+// envField returns the arg that the environment variable name, without the prefix, sets. A shell
+// can not export a name with a dot so the dots of nested args are written as __, and the name is
+// matched ignoring case if no arg matches it exactly, for example DB__HOST sets db.host.
+func (f *Flags) envField(name string) (fieldFlag, bool) {
+	var folded []fieldFlag
+	for argName, field := range f.fieldFlags {
+		envName := strings.ReplaceAll(argName, ".", "__")
+		if envName == name {
+			return field, true
+		} else if strings.EqualFold(envName, name) {
+			folded = append(folded, field)
+		}
+	}
+	if len(folded) == 1 {
+		return folded[0], true
+	}
+	return fieldFlag{}, false
 }
 
+func parseEnvValue(field schema.Field, v string) (any, error) {
+	switch {
+	case field.Type.Kind == schema.BoolKind:
+		return strconv.ParseBool(v)
+	case mapValueKind(field.Type) != "":
+		items, err := csv.NewReader(strings.NewReader(v)).Read()
+		if err != nil {
+			return nil, err
+		}
+		return parseMap(items, mapValueKind(field.Type))
+	case field.Type.Kind == schema.ArrayKind:
+		// the same format as array flags, comma separated with CSV quoting
+		items, err := csv.NewReader(strings.NewReader(v)).Read()
+		if err != nil {
+			return nil, err
+		}
+		vals := []any{}
+		for _, item := range items {
+			val, err := parseValue(item, field.Type.Array != nil && field.Type.Array.Items.Kind == schema.NumberKind)
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, val)
+		}
+		return vals, nil
+	}
+	return parseValue(v, field.Type.Kind == schema.NumberKind)
+}
+
+// Parse returns the args and profiles from all the sources, see Options for the precedence of the
+// sources. The returned args do not include the defaults or the values set by profiles, those
+// are applied when the file is evaluated.
 func (f *Flags) Parse(args []string) (map[string]any, []string, error) {
-	result, err := f.readArgsFile()
-	if err != nil {
+	result := map[string]any{}
+	f.sources = map[string]string{}
+
+	if err := f.readArgsFiles(result); err != nil {
+		return nil, nil, err
+	}
+
+	if err := f.readEnv(result); err != nil {
 		return nil, nil, err
 	}
 
@@ -146,32 +479,148 @@ func (f *Flags) Parse(args []string) (map[string]any, []string, error) {
 		return nil, nil, err
 	}
 
-	for name, field := range f.fieldFlags {
-		flag := f.FlagSet.Lookup(name)
-
-		switch {
-		case !flag.Changed:
-		case field.Bool != nil:
-			result[name] = *field.Bool
-		case field.StringSlice != nil:
-			vals := []any{}
-			for _, str := range *field.StringSlice {
-				val, err := parseValue(str, field.Field.Type.Array.Items.Kind == schema.NumberKind)
-				if err != nil {
-					return nil, nil, err
-				}
-				vals = append(vals, val)
-			}
-			result[name] = vals
-		default:
-			result[name], err = parseValue(*field.String, field.Field.Type.Kind == schema.NumberKind)
+	// parents are set before the nested values so that --db.host overrides the host of --db
+	names := make([]string, 0, len(f.fieldFlags))
+	for name := range f.fieldFlags {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		left, right := len(f.fieldFlags[names[i]].Path), len(f.fieldFlags[names[j]].Path)
+		if left != right {
+			return left < right
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		if !f.FlagSet.Lookup(name).Changed {
+			continue
+		}
+
+		field := f.fieldFlags[name]
+		val, err := field.value()
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid value for flag --%s: %w", name, err)
+		}
+		setPath(result, field.Path, val, field.Map != nil)
+		f.setSource(field.Path, "flag --"+name, field.Map != nil)
+	}
+
+	return result, *f.profile, nil
+}
+
+func (f fieldFlag) value() (any, error) {
+	switch {
+	case f.Bool != nil:
+		return *f.Bool, nil
+	case f.Map != nil:
+		return parseMap(*f.Map, mapValueKind(f.Field.Type))
+	case f.StringSlice != nil:
+		vals := []any{}
+		for _, str := range *f.StringSlice {
+			val, err := parseValue(str, f.Field.Type.Array != nil && f.Field.Type.Array.Items.Kind == schema.NumberKind)
 			if err != nil {
-				return nil, nil, err
+				return nil, err
 			}
+			vals = append(vals, val)
 		}
+		return vals, nil
 	}
+	return parseValue(*f.String, f.Field.Type.Kind == schema.NumberKind)
+}
 
-	return result, *f.profile, nil
+// EffectiveArgs returns every arg that has a value after Parse, including the defaults and the
+// values set by the profiles, sorted by name. The values of sensitive args are redacted unless
+// value.RevealSecrets is set.
+func (f *Flags) EffectiveArgs(args map[string]any, profiles []string) (result []EffectiveArg) {
+	values := map[string]EffectiveArg{}
+	for name, value := range f.defaults {
+		values[name] = EffectiveArg{Name: name, Value: value, Source: "default"}
+	}
+	for _, profile := range profiles {
+		for name, value := range f.profiles[profile] {
+			values[name] = EffectiveArg{Name: name, Value: value, Source: "profile " + profile}
+		}
+	}
+	for name, value := range args {
+		values[name] = EffectiveArg{Name: name, Value: value, Source: f.sources[name]}
+	}
+
+	for _, arg := range values {
+		if field, ok := f.fieldFlags[arg.Name]; ok && !value.RevealSecrets {
+			arg.Value = redactSensitive(field.Field.Type, arg.Value)
+		}
+		result = append(result, arg)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// redactSensitive replaces the value with value.Redacted if the type is sensitive, or the values
+// of the sensitive fields if it is an object.
+func redactSensitive(fieldType schema.FieldType, v any) any {
+	if fieldType.Sensitive {
+		return value.Redacted
+	}
+	obj, ok := v.(map[string]any)
+	if !ok || fieldType.Object == nil {
+		return v
+	}
+
+	result := make(map[string]any, len(obj))
+	for key, item := range obj {
+		result[key] = item
+	}
+	for _, field := range fieldType.Object.Fields {
+		if item, ok := result[field.Name]; ok && !field.Match {
+			result[field.Name] = redactSensitive(field.Type, item)
+		}
+	}
+	return result
+}
+
+// Values returns the known values of a flag, the names of the profiles for --profile and the
+// values of args that only allow a fixed set of values, such as enum("a", "b"), in the order they
+// are written and without duplicates
+func (f *Flags) Values(name string) (result []string) {
+	if name == "profile" && f.profile != nil {
+		for _, profile := range f.profileNames {
+			result = append(result, profile.Name)
+		}
+		return result
+	}
+
+	field, ok := f.fieldFlags[name]
+	if !ok {
+		return nil
+	}
+	var (
+		fieldType = field.Field.Type
+		seen      = map[string]bool{}
+	)
+	for alt := &fieldType; alt != nil; alt = alt.Alternate {
+		v, ok := equalsValue(*alt)
+		if !ok {
+			// any alternate that is not a fixed value allows other values
+			return nil
+		}
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func equalsValue(fieldType schema.FieldType) (string, bool) {
+	for _, constraint := range fieldType.Constraint {
+		if constraint.Op == "==" && constraint.Left == nil && constraint.Right != nil {
+			return fmt.Sprint(constraint.Right), true
+		}
+	}
+	return "", false
 }
 
 func (f *Flags) flagChanged(name string) bool {
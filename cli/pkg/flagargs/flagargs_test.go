@@ -2,6 +2,8 @@ package flagargs
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"os"
 	"testing"
 
@@ -13,9 +15,11 @@ import (
 
 func TestParseArgs(t *testing.T) {
 	args, profiles, err := ParseArgs(
-		"testdata/TestParseArgs/input-args.acorn",
 		"testdata/TestParseArgs/input.acorn",
-		[]string{"--foo", "bar", "--profile=one", "--profile", "two"})
+		[]string{"--foo", "bar", "--profile=one", "--profile", "two"},
+		Options{
+			ArgsFiles: []string{"testdata/TestParseArgs/input-args.acorn"},
+		})
 	require.NoError(t, err)
 
 	autogold.Expect([]string{
@@ -29,9 +33,11 @@ func TestParseArgs(t *testing.T) {
 
 func TestParseInvalidFlag(t *testing.T) {
 	_, _, err := ParseArgs(
-		"testdata/TestParseArgs/input-args.acorn",
 		"testdata/TestParseArgs/input.acorn",
-		[]string{"--foo2", "bar"})
+		[]string{"--foo2", "bar"},
+		Options{
+			ArgsFiles: []string{"testdata/TestParseArgs/input-args.acorn"},
+		})
 
 	autogold.Expect("unknown flag: --foo2").Equal(t, err.Error())
 }
@@ -48,13 +54,182 @@ func TestHelp(t *testing.T) {
 	buffer := &bytes.Buffer{}
 
 	flags := New(
-		"testdata/TestParseArgs/input-args.acorn",
+		[]string{"testdata/TestParseArgs/input-args.acorn"},
 		"testdata/TestParseArgs/input.acorn",
 		file.ProfileNames,
-		file.Args)
+		file.Args.Fields)
 	flags.FlagSet.SetOutput(buffer)
 
 	_, _, err = flags.Parse([]string{"--help"})
 	autogold.Expect("pflag: help requested").Equal(t, err.Error())
 	autogold.ExpectFile(t, autogold.Raw(buffer.String()))
 }
+
+func TestParseSources(t *testing.T) {
+	flags, err := Load("testdata/TestParseSources/input.acorn", Options{
+		ArgsFiles: []string{
+			"testdata/TestParseSources/base.acorn",
+			"testdata/TestParseSources/missing.acorn",
+			"testdata/TestParseSources/prod.acorn",
+		},
+		EnvPrefix: DefaultEnvPrefix,
+		Environ: []string{
+			"AML_ARG_replicas=3",
+			"AML_ARG_ports=80,443",
+			"AML_ARG_unknown=x",
+			"OTHER_debug=true",
+		},
+	})
+	require.NoError(t, err)
+
+	args, profiles, err := flags.Parse([]string{"--replicas", "5", "--profile", "dev"})
+	require.NoError(t, err)
+
+	autogold.Expect(`{"ports":[80,443],"region":"us-west","replicas":5,"tag":"v2"}`).Equal(t, toJSON(t, args))
+	autogold.Expect(`debug=true (profile dev)
+ports=[80,443] (environment variable AML_ARG_ports)
+region="us-west" (args file testdata/TestParseSources/base.acorn)
+replicas=5 (flag --replicas)
+tag="v2" (args file testdata/TestParseSources/prod.acorn)
+`).Equal(t, effectiveArgs(t, flags, args, profiles))
+}
+
+func TestParseMissingArgsFile(t *testing.T) {
+	flags, err := Load("testdata/TestParseSources/input.acorn", Options{
+		ArgsFiles: []string{
+			"testdata/TestParseSources/base.acorn",
+			"testdata/TestParseSources/missing.acorn",
+		},
+		RequireArgsFiles: true,
+	})
+	require.NoError(t, err)
+
+	_, _, err = flags.Parse(nil)
+	autogold.Expect("open testdata/TestParseSources/missing.acorn: no such file or directory").Equal(t, err.Error())
+}
+
+func toJSON(t *testing.T, v any) string {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func effectiveArgs(t *testing.T, flags *Flags, args map[string]any, profiles []string) string {
+	buf := &bytes.Buffer{}
+	for _, arg := range flags.EffectiveArgs(args, profiles) {
+		fmt.Fprintf(buf, "%s=%s (%s)\n", arg.Name, toJSON(t, arg.Value), arg.Source)
+	}
+	return buf.String()
+}
+
+func TestParseInvalidEnv(t *testing.T) {
+	_, _, err := ParseArgs("testdata/TestParseSources/input.acorn", nil, Options{
+		EnvPrefix: "X_",
+		Environ:   []string{"X_debug=maybe"},
+	})
+	autogold.Expect(`invalid value for environment variable X_debug: strconv.ParseBool: parsing "maybe": invalid syntax`).Equal(t, err.Error())
+}
+
+func TestParseNested(t *testing.T) {
+	flags, err := Load("testdata/TestParseNested/input.acorn", Options{
+		EnvPrefix: DefaultEnvPrefix,
+		Environ:   []string{"AML_ARG_labels=team=web"},
+	})
+	require.NoError(t, err)
+
+	args, profiles, err := flags.Parse([]string{
+		"--db", `@{host: "db", user: "admin"}`,
+		"--db.port", "3306",
+		"--db.tls.enabled",
+		"--labels", "app=web",
+		"--labels", "tier=frontend",
+	})
+	require.NoError(t, err)
+
+	autogold.Expect(`{"db":{"host":"db","port":3306,"tls":{"enabled":true},"user":"admin"},"labels":{"app":"web","team":"web","tier":"frontend"}}`).Equal(t, toJSON(t, args))
+	autogold.Expect(`db={"host":"db","port":3306,"tls":{"enabled":true},"user":"admin"} (flag --db, flag --db.port, flag --db.tls.enabled)
+labels={"app":"web","team":"web","tier":"frontend"} (environment variable AML_ARG_labels, flag --labels)
+name="x" (default)
+`).Equal(t, effectiveArgs(t, flags, args, profiles))
+
+	_, _, err = flags.Parse([]string{"--labels", "app"})
+	autogold.Expect(`invalid value for flag --labels: invalid value "app", must be key=value`).Equal(t, err.Error())
+}
+
+func TestParseNestedEnv(t *testing.T) {
+	flags, err := Load("testdata/TestParseNested/input.acorn", Options{
+		EnvPrefix: DefaultEnvPrefix,
+		Environ:   []string{"AML_ARG_db__port=3306", "AML_ARG_DB__TLS__ENABLED=true", "AML_ARG_db.host=x"},
+	})
+	require.NoError(t, err)
+
+	args, profiles, err := flags.Parse(nil)
+	require.NoError(t, err)
+
+	autogold.Expect(`{"db":{"port":3306,"tls":{"enabled":true}}}`).Equal(t, toJSON(t, args))
+	autogold.Expect(`db={"port":3306,"tls":{"enabled":true}} (environment variable AML_ARG_db__port, environment variable AML_ARG_DB__TLS__ENABLED)
+name="x" (default)
+`).Equal(t, effectiveArgs(t, flags, args, profiles))
+}
+
+func TestHelpNested(t *testing.T) {
+	flags, err := Load("testdata/TestParseNested/input.acorn", Options{})
+	require.NoError(t, err)
+
+	buffer := &bytes.Buffer{}
+	flags.FlagSet.SetOutput(buffer)
+	_, _, err = flags.Parse([]string{"--help"})
+	require.Error(t, err)
+	autogold.ExpectFile(t, autogold.Raw(buffer.String()))
+}
+
+func TestValues(t *testing.T) {
+	flags, err := Load("testdata/TestValues/input.acorn", Options{})
+	require.NoError(t, err)
+
+	autogold.Expect([]string{"dev", "prod"}).Equal(t, flags.Values("profile"))
+	autogold.Expect([]string{"debug", "info", "warn"}).Equal(t, flags.Values("level"))
+	autogold.Expect([]string{"fast", "slow"}).Equal(t, flags.Values("mode"))
+	autogold.Expect([]string{"fast", "slow"}).Equal(t, flags.Values("speed"))
+	autogold.Expect([]string{"small"}).Equal(t, flags.Values("size"))
+	autogold.Expect([]string(nil)).Equal(t, flags.Values("name"))
+	autogold.Expect([]string(nil)).Equal(t, flags.Values("missing"))
+}
+
+func TestMissing(t *testing.T) {
+	flags, err := Load("testdata/TestMissing/input.acorn", Options{})
+	require.NoError(t, err)
+
+	args, profiles, err := flags.Parse([]string{"--name", "app", "--profile", "dev"})
+	require.NoError(t, err)
+
+	var names []string
+	for _, field := range flags.Missing(args, profiles) {
+		names = append(names, field.Name)
+	}
+	autogold.Expect([]string{"replicas", "debug"}).Equal(t, names)
+
+	val, err := flags.ParseValue("replicas", "3")
+	require.NoError(t, err)
+	require.NoError(t, flags.Validate("replicas", val))
+
+	val, err = flags.ParseValue("replicas", "0")
+	require.NoError(t, err)
+	autogold.Expect("unmatched constraint 0 > 0").Equal(t, flags.Validate("replicas", val).Error())
+
+	val, err = flags.ParseValue("debug", "yes")
+	require.NoError(t, err)
+	autogold.Expect(true).Equal(t, val)
+}
+
+func TestEffectiveArgsSensitive(t *testing.T) {
+	flags, err := Load("testdata/TestEffectiveArgsSensitive/input.acorn", Options{})
+	require.NoError(t, err)
+
+	args, profiles, err := flags.Parse([]string{"--password", "hunter2", "--db.pass", "secret", "--db.host", "db"})
+	require.NoError(t, err)
+
+	autogold.Expect(`db={"host":"db","pass":"(redacted)"} (flag --db.host, flag --db.pass)
+password="(redacted)" (flag --password)
+`).Equal(t, effectiveArgs(t, flags, args, profiles))
+}
@@ -0,0 +1,78 @@
+package flagargs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/acorn-io/aml/pkg/schema"
+	"github.com/acorn-io/aml/pkg/value"
+)
+
+// Missing returns the required args that have no value after Parse. An arg is required if it is
+// not optional and has no default, args set by the selected profiles are not missing.
+func (f *Flags) Missing(args map[string]any, profiles []string) (result []schema.Field) {
+	for _, field := range f.args {
+		if field.Match || field.Optional || field.Type.DefaultValue() != nil {
+			continue
+		}
+		if _, ok := f.fieldFlags[field.Name]; !ok {
+			continue
+		}
+		if _, ok := args[field.Name]; ok {
+			continue
+		}
+		if setByProfile(f.profiles, profiles, field.Name) {
+			continue
+		}
+		result = append(result, field)
+	}
+	return result
+}
+
+func setByProfile(values map[string]map[string]any, profiles []string, name string) bool {
+	for _, profile := range profiles {
+		if _, ok := values[profile][name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseValue parses the value of an arg from a string in the same format as the environment
+// variables. Bool args also accept yes and no, and object args an @ value.
+func (f *Flags) ParseValue(name, v string) (any, error) {
+	field, ok := f.fieldFlags[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown arg %s", name)
+	}
+	if field.Field.Type.Kind == schema.BoolKind {
+		switch strings.ToLower(v) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		return strconv.ParseBool(v)
+	}
+	if field.Field.Type.Kind == schema.ObjectKind && strings.HasPrefix(v, "@") {
+		return parseValue(v, false)
+	}
+	return parseEnvValue(field.Field, v)
+}
+
+// Validate checks the value of an arg against the type and constraints of the arg in the file.
+// Values are only checked if the flags were created with Load.
+func (f *Flags) Validate(name string, v any) error {
+	if f.argsSchema == nil {
+		return nil
+	}
+	fieldSchema, ok, err := value.Lookup(f.argsSchema, value.NewValue(name))
+	if err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("unknown arg %s", name)
+	}
+	_, err = value.Merge(fieldSchema, value.NewValue(v))
+	return err
+}
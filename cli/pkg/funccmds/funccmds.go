@@ -0,0 +1,212 @@
+package funccmds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/acorn-io/aml"
+	"github.com/acorn-io/aml/cli/pkg/flagargs"
+	"github.com/acorn-io/aml/cli/pkg/output"
+	"github.com/acorn-io/aml/pkg/eval"
+	"github.com/acorn-io/aml/pkg/value"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+type Options struct {
+	// Args and Profiles are passed to the file when it is evaluated to find the functions
+	Args     map[string]any
+	Profiles []string
+	// Output is where the result of a command is written, if nil os.Stdout is used
+	Output io.Writer
+	// Format is the default output format of the commands, if empty json is used
+	Format string
+	// Args of the functions are also read from these sources, see flagargs.Options
+	FlagArgs flagargs.Options
+}
+
+func (o Options) complete() Options {
+	if o.Output == nil {
+		o.Output = os.Stdout
+	}
+	if o.Format == "" {
+		o.Format = output.JSON
+	}
+	return o
+}
+
+// Commands returns a command for each top-level function of the file. The args of a function are
+// flags of the command and can also be passed as positional args in the order they are declared.
+// The comment of the function is the help text of the command and the return value is written in
+// the format selected with --output.
+func Commands(ctx context.Context, acornFile string, opts Options) ([]*cobra.Command, error) {
+	opts = opts.complete()
+
+	data, err := os.ReadFile(acornFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var file eval.File
+	if err := aml.Unmarshal(data, &file, aml.DecoderOption{
+		SourceName: acornFile,
+		Args:       opts.Args,
+		Profiles:   opts.Profiles,
+		Context:    ctx,
+	}); err != nil {
+		return nil, err
+	}
+
+	scope, err := file.Scope(eval.Builtin.Push(nil, eval.ScopeOption{
+		Context: ctx,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*cobra.Command
+	for _, field := range file.Body.Fields {
+		kv, ok := field.(*eval.KeyValue)
+		if !ok || kv.Local || kv.Key.IsMatch() {
+			continue
+		}
+		if _, ok := kv.Value.(*eval.FunctionDefinition); !ok {
+			continue
+		}
+
+		name, ok, err := kv.Key.ToString(scope)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+
+		val, _, ok, err := file.ToValueForPath(scope, []string{name})
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		fun, ok := val.(*eval.Function)
+		if !ok {
+			continue
+		}
+
+		c, err := newCommand(name, kv.Comments.Last(), fun, opts)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, c)
+	}
+
+	return result, nil
+}
+
+type command struct {
+	name   string
+	fun    *eval.Function
+	flags  *flagargs.Flags
+	format *string
+	opts   Options
+}
+
+func newCommand(name, description string, fun *eval.Function, opts Options) (*cobra.Command, error) {
+	flags, err := flagargs.FromFunction(name, fun, opts.FlagArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &command{
+		name:  name,
+		fun:   fun,
+		flags: flags,
+		opts:  opts,
+	}
+	if len(fun.ProfileNames) == 0 {
+		_ = flags.FlagSet.MarkHidden("profile")
+	}
+	if flags.FlagSet.Lookup("output") == nil {
+		c.format = flags.FlagSet.StringP("output", "o", opts.Format, "Output format ("+strings.Join(output.Formats, ", ")+")")
+	} else {
+		c.format = &opts.Format
+	}
+
+	use := name + " [flags]"
+	for _, arg := range fun.ArgNames {
+		use += " [" + strings.ToUpper(arg.Name) + "]"
+	}
+
+	short, _, _ := strings.Cut(description, "\n")
+	result := &cobra.Command{
+		Use:                use,
+		Short:              short,
+		Long:               description,
+		Args:               cobra.ArbitraryArgs,
+		DisableFlagParsing: true,
+		SilenceErrors:      true,
+		RunE:               c.run,
+	}
+
+	return result, nil
+}
+
+func (c *command) run(cmd *cobra.Command, args []string) error {
+	// flag parsing is done by the flags of the function so help is printed here
+	c.flags.FlagSet.SetOutput(cmd.OutOrStderr())
+	c.flags.Usage = func() {
+		if cmd.Long != "" {
+			fmt.Fprintf(cmd.OutOrStderr(), "%s\n\n", cmd.Long)
+		}
+		fmt.Fprintf(cmd.OutOrStderr(), "Usage:\n  %s\n\nFlags:\n", cmd.UseLine())
+	}
+
+	argsData, profiles, err := c.flags.Parse(args)
+	if errors.Is(err, pflag.ErrHelp) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	positional := c.flags.FlagSet.Args()
+	if len(positional) > len(c.fun.ArgNames) {
+		return fmt.Errorf("%s accepts at most %d positional arg(s), received %d", c.name, len(c.fun.ArgNames), len(positional))
+	}
+	for i, raw := range positional {
+		name := c.fun.ArgNames[i].Name
+		if _, ok := argsData[name]; ok {
+			return fmt.Errorf("arg %s is set by both a flag and a positional arg", name)
+		}
+		v, err := c.flags.ParseValue(name, raw)
+		if err != nil {
+			return fmt.Errorf("invalid value for arg %s: %w", name, err)
+		}
+		argsData[name] = v
+	}
+
+	callArgs := []value.CallArgument{{
+		Value: value.NewValue(argsData),
+	}}
+	if len(profiles) > 0 {
+		var profileValues []any
+		for _, profile := range profiles {
+			profileValues = append(profileValues, profile)
+		}
+		callArgs = append(callArgs, value.CallArgument{
+			Value: value.NewValue(map[string]any{
+				"profiles": value.NewValue(profileValues),
+			}),
+		})
+	}
+
+	result, ok, err := value.Call(cmd.Context(), c.fun, callArgs...)
+	if err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("function %s did not return a value", c.name)
+	}
+	return output.Write(c.opts.Output, *c.format, result)
+}
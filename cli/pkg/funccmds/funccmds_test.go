@@ -0,0 +1,72 @@
+package funccmds
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func run(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	out := &bytes.Buffer{}
+	cmds, err := Commands(context.Background(), "testdata/input.acorn", Options{
+		Output: out,
+	})
+	require.NoError(t, err)
+
+	root := &cobra.Command{Use: "tool"}
+	root.SetOut(out)
+	root.SetErr(out)
+	root.AddCommand(cmds...)
+	root.SetArgs(args)
+	err = root.Execute()
+	return out.String(), err
+}
+
+func TestCommands(t *testing.T) {
+	out, err := run(t, "greet", "--loud", "--times=2", "world")
+	require.NoError(t, err)
+	autogold.Expect(`{
+    "message": "Hello, world",
+    "loud": true,
+    "times": 2
+}
+`).Equal(t, out)
+
+	out, err = run(t, "greet", "--profile", "formal", "-o", "yaml")
+	require.NoError(t, err)
+	autogold.Expect(`message: Hello, Sir
+loud: false
+times: 1
+`).Equal(t, out)
+
+	out, err = run(t, "add", "1", "2")
+	require.NoError(t, err)
+	autogold.Expect("3\n").Equal(t, out)
+}
+
+func TestCommandsErrors(t *testing.T) {
+	_, err := run(t, "add", "1", "2", "3")
+	autogold.Expect("add accepts at most 2 positional arg(s), received 3").Equal(t, err.Error())
+
+	_, err = run(t, "add", "--a", "1", "2")
+	autogold.Expect("arg a is set by both a flag and a positional arg").Equal(t, err.Error())
+
+	_, err = run(t, "greet", "--times", "0", "x")
+	require.Error(t, err)
+}
+
+func TestHelp(t *testing.T) {
+	out, err := run(t, "--help")
+	require.NoError(t, err)
+	autogold.ExpectFile(t, autogold.Raw(out), autogold.Name("TestHelpRoot"))
+
+	out, err = run(t, "greet", "--help")
+	require.NoError(t, err)
+	autogold.ExpectFile(t, autogold.Raw(out))
+}
@@ -0,0 +1,189 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/acorn-io/aml"
+	"github.com/acorn-io/aml/pkg/value"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	JSON       = "json"
+	YAML       = "yaml"
+	AML        = "aml"
+	TOML       = "toml"
+	Env        = "env"
+	Properties = "properties"
+)
+
+var Formats = []string{JSON, YAML, AML, TOML, Env, Properties}
+
+// Write writes data to w in the given format. If data is a value.Value the order of the object
+// keys is preserved for the formats that support it and secrets are redacted unless
+// value.RevealSecrets is set.
+func Write(w io.Writer, format string, data any) error {
+	if v, ok := data.(value.Value); ok && !value.RevealSecrets {
+		data = value.Redact(v)
+	}
+
+	if format == JSON {
+		return writeJSON(w, data)
+	}
+
+	node, err := toNode(data)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case YAML:
+		return writeYAML(w, node)
+	case AML:
+		out, err := aml.Marshal(node)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	case TOML:
+		return writeTOML(w, node)
+	case Env:
+		return writeFlat(w, format, node, envLine)
+	case Properties:
+		return writeFlat(w, format, node, propertiesLine)
+	}
+	return fmt.Errorf("invalid output format %q, must be one of %s", format, strings.Join(Formats, ", "))
+}
+
+func writeJSON(w io.Writer, data any) error {
+	if v, ok := data.(value.Value); ok {
+		nv, ok, err := value.OrderedNativeValue(v)
+		if err != nil {
+			return err
+		} else if !ok {
+			return fmt.Errorf("value kind %s can not be converted to JSON", v.Kind())
+		}
+		data = nv
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(data)
+}
+
+// toNode converts data to a YAML node tree which is used as the ordered representation for
+// all formats other than JSON.
+func toNode(data any) (*yaml.Node, error) {
+	if v, ok := data.(value.Value); ok {
+		nv, ok, err := value.OrderedNativeValue(v)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, fmt.Errorf("value kind %s can not be converted to a native value", v.Kind())
+		}
+		return value.OrderedYAMLNode(nv)
+	}
+
+	// JSON is valid YAML, going through JSON respects the json tags and field order of structs
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(jsonData, doc); err != nil {
+		return nil, err
+	}
+	clearStyle(doc)
+	return doc.Content[0], nil
+}
+
+// clearStyle removes the flow and quoting styles that parsing JSON sets so that the node is
+// written in the default style of each format
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearStyle(child)
+	}
+}
+
+// writeYAML writes the node as YAML. A top-level array is written as a multi-document stream.
+func writeYAML(w io.Writer, node *yaml.Node) error {
+	docs := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		docs = node.Content
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+// writeFlat writes the fields of a top-level object one per line. Nested values are not
+// supported by the flat formats.
+func writeFlat(w io.Writer, format string, node *yaml.Node, line func(key, value string) (string, error)) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s output requires an object, got %s", format, node.ShortTag())
+	}
+
+	buf := &bytes.Buffer{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, val := node.Content[i].Value, node.Content[i+1]
+		if val.Kind != yaml.ScalarNode {
+			return fmt.Errorf("%s output does not support nested values, key %q has a value of type %s", format, key, val.ShortTag())
+		}
+
+		v := val.Value
+		if val.ShortTag() == "!!null" {
+			v = ""
+		}
+
+		text, err := line(key, v)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(text)
+		buf.WriteString("\n")
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+var (
+	envName        = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	envSafeValue   = regexp.MustCompile(`^[A-Za-z0-9_./:,+@%=-]*$`)
+	propertiesKeys = strings.NewReplacer(`\`, `\\`, " ", `\ `, "=", `\=`, ":", `\:`, "#", `\#`, "!", `\!`)
+	propertiesVals = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+)
+
+func envLine(key, value string) (string, error) {
+	if !envName.MatchString(key) {
+		return "", fmt.Errorf("key %q is not a valid environment variable name", key)
+	}
+	if envSafeValue.MatchString(value) {
+		return key + "=" + value, nil
+	}
+	// single quotes keep everything literal in a POSIX shell, a single quote is written by
+	// closing the quotes, escaping it and opening them again
+	return key + "='" + strings.ReplaceAll(value, "'", `'\''`) + "'", nil
+}
+
+func propertiesLine(key, value string) (string, error) {
+	value = propertiesVals.Replace(value)
+	if strings.HasPrefix(value, " ") {
+		value = `\` + value
+	}
+	return propertiesKeys.Replace(key) + "=" + value, nil
+}
@@ -0,0 +1,209 @@
+package output
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/acorn-io/aml"
+	"github.com/acorn-io/aml/pkg/value"
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	var data value.Value
+	err := aml.Unmarshal([]byte(`
+zeta:  1
+alpha: "x y"
+empty: null
+text:  "line one\nline two"
+`), &data)
+	require.NoError(t, err)
+
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			require.NoError(t, Write(buf, format, data))
+			autogold.ExpectFile(t, autogold.Raw(buf.String()))
+		})
+	}
+}
+
+func TestWriteNested(t *testing.T) {
+	var data value.Value
+	err := aml.Unmarshal([]byte(`[{b: 2, a: [1, 2.5]}, {c: true}]`), &data)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf, YAML, data))
+	autogold.Expect(`b: 2
+a:
+  - 1
+  - 2.5
+---
+c: true
+`).Equal(t, buf.String())
+
+	err = Write(buf, Env, data)
+	autogold.Expect("env output requires an object, got !!seq").Equal(t, err.Error())
+
+	err = aml.Unmarshal([]byte(`a: {b: 1}`), &data)
+	require.NoError(t, err)
+
+	err = Write(buf, Properties, data)
+	autogold.Expect(`properties output does not support nested values, key "a" has a value of type !!map`).Equal(t, err.Error())
+}
+
+func TestWriteEnv(t *testing.T) {
+	var data value.Value
+	err := aml.Unmarshal([]byte(`
+PLAIN: "v1.2"
+VAR:   "$HOME"
+CMD:   "`+"`id`"+` $(id)"
+LINES: "one\ntwo"
+QUOTE: "it's"
+`), &data)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf, Env, data))
+	autogold.Expect(`PLAIN=v1.2
+VAR='$HOME'
+CMD='`+"`id`"+` $(id)'
+LINES='one
+two'
+QUOTE='it'\''s'
+`).Equal(t, buf.String())
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh is not installed")
+	}
+	out, err := exec.Command(sh, "-c", buf.String()+`printf '%s|' "$PLAIN" "$VAR" "$CMD" "$LINES" "$QUOTE"`).Output()
+	require.NoError(t, err)
+	autogold.Expect("v1.2|$HOME|`id` $(id)|one\ntwo|it's|").Equal(t, string(out))
+}
+
+func TestWriteTopLevel(t *testing.T) {
+	var data value.Value
+	err := aml.Unmarshal([]byte(`[1, 2]`), &data)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf, YAML, data))
+	autogold.Expect("1\n---\n2\n").Equal(t, buf.String())
+
+	err = aml.Unmarshal([]byte(`42`), &data)
+	require.NoError(t, err)
+
+	buf.Reset()
+	require.NoError(t, Write(buf, YAML, data))
+	autogold.Expect("42\n").Equal(t, buf.String())
+}
+
+func TestWriteNumbers(t *testing.T) {
+	var data value.Value
+	err := aml.Unmarshal([]byte(`
+f:    1.0e3
+big:  12345678901234567890
+huge: 123456789012345678901234567890
+k:    2k
+`), &data)
+	require.NoError(t, err)
+
+	for _, format := range []string{YAML, AML} {
+		t.Run(format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			require.NoError(t, Write(buf, format, data))
+			autogold.ExpectFile(t, autogold.Raw(buf.String()))
+
+		})
+	}
+
+	// the integers are written exactly so that reading the AML output gives the same values
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf, AML, data))
+
+	var out value.Value
+	require.NoError(t, aml.Unmarshal(buf.Bytes(), &out))
+	for _, key := range []string{"big", "huge"} {
+		want, _, err := value.Lookup(data, value.NewValue(key))
+		require.NoError(t, err)
+		got, _, err := value.Lookup(out, value.NewValue(key))
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+}
+
+func TestWriteTOML(t *testing.T) {
+	var data value.Value
+	err := aml.Unmarshal([]byte(`
+name:  "web"
+db:    {port: 5432, host: "localhost", "user name": "admin"}
+ports: [80, 443]
+empty: null
+f:     1.0e3
+containers: [{name: "a", env: {LOG: "info"}}, {name: "b\t\"c\""}]
+mixed: [1, {a: true}]
+`), &data)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf, TOML, data))
+	autogold.ExpectFile(t, autogold.Raw(buf.String()))
+
+	var out map[string]any
+	_, err = toml.Decode(buf.String(), &out)
+	require.NoError(t, err)
+
+	err = aml.Unmarshal([]byte(`big: 12345678901234567890`), &data)
+	require.NoError(t, err)
+
+	err = Write(buf, TOML, data)
+	autogold.Expect(`key "big": integer 12345678901234567890 does not fit a TOML integer`).Equal(t, err.Error())
+}
+
+func TestWriteStruct(t *testing.T) {
+	data := []struct {
+		Name  string `json:"name"`
+		Value any    `json:"value"`
+	}{{Name: "a", Value: map[string]any{"b": "c"}}}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf, YAML, data))
+	autogold.Expect(`name: a
+value:
+  b: c
+`).Equal(t, buf.String())
+}
+
+func TestWriteSecrets(t *testing.T) {
+	var data value.Value
+	err := aml.Unmarshal([]byte(`
+user:     "admin"
+password: std.secret("hunter2")
+dsn:      "\(user):\(password)@db"
+`), &data)
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf, Env, data))
+	autogold.Expect(`user=admin
+password='(redacted)'
+dsn='(redacted)'
+`).Equal(t, buf.String())
+
+	value.RevealSecrets = true
+	defer func() {
+		value.RevealSecrets = false
+	}()
+
+	buf.Reset()
+	require.NoError(t, Write(buf, Env, data))
+	autogold.Expect(`user=admin
+password=hunter2
+dsn=admin:hunter2@db
+`).Equal(t, buf.String())
+}
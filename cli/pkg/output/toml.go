@@ -0,0 +1,205 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// writeTOML writes the node as TOML keeping the order of the keys. The values of a table are
+// written before its sub-tables as TOML requires, null values are left out.
+func writeTOML(w io.Writer, node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("toml output requires an object, got %s", node.ShortTag())
+	}
+
+	buf := &bytes.Buffer{}
+	if err := writeTOMLTable(buf, nil, node); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeTOMLTable(buf *bytes.Buffer, path []string, node *yaml.Node) error {
+	var tables []int
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, val := node.Content[i].Value, node.Content[i+1]
+		switch {
+		case val.ShortTag() == "!!null":
+			continue
+		case val.Kind == yaml.MappingNode || isTOMLTableArray(val):
+			tables = append(tables, i)
+			continue
+		}
+
+		text, err := tomlValue(val)
+		if err != nil {
+			return fmt.Errorf("key %q: %w", strings.Join(append(path, key), "."), err)
+		}
+		fmt.Fprintf(buf, "%s = %s\n", tomlKey(key), text)
+	}
+
+	for _, i := range tables {
+		key, val := node.Content[i].Value, node.Content[i+1]
+		tablePath := append(path[:len(path):len(path)], key)
+
+		if val.Kind == yaml.MappingNode {
+			tomlHeader(buf, "[%s]\n", tablePath)
+			if err := writeTOMLTable(buf, tablePath, val); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, item := range val.Content {
+			tomlHeader(buf, "[[%s]]\n", tablePath)
+			if err := writeTOMLTable(buf, tablePath, item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func tomlHeader(buf *bytes.Buffer, format string, path []string) {
+	if buf.Len() > 0 {
+		buf.WriteString("\n")
+	}
+	keys := make([]string, 0, len(path))
+	for _, key := range path {
+		keys = append(keys, tomlKey(key))
+	}
+	fmt.Fprintf(buf, format, strings.Join(keys, "."))
+}
+
+// isTOMLTableArray returns true if the node is an array of objects, which is written as an array
+// of tables
+func isTOMLTableArray(node *yaml.Node) bool {
+	if node.Kind != yaml.SequenceNode || len(node.Content) == 0 {
+		return false
+	}
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlValue returns the inline representation of the node
+func tomlValue(node *yaml.Node) (string, error) {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			text, err := tomlValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, text)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case yaml.MappingNode:
+		var fields []string
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i+1].ShortTag() == "!!null" {
+				continue
+			}
+			text, err := tomlValue(node.Content[i+1])
+			if err != nil {
+				return "", err
+			}
+			fields = append(fields, tomlKey(node.Content[i].Value)+" = "+text)
+		}
+		return "{" + strings.Join(fields, ", ") + "}", nil
+	case yaml.ScalarNode:
+		return tomlScalar(node)
+	}
+	return "", fmt.Errorf("unsupported value of type %s", node.ShortTag())
+}
+
+func tomlScalar(node *yaml.Node) (string, error) {
+	switch node.ShortTag() {
+	case "!!null":
+		return "", fmt.Errorf("toml can not represent null in an array")
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return "", err
+		}
+		return strconv.FormatBool(b), nil
+	case "!!int":
+		var i int64
+		if err := node.Decode(&i); err != nil {
+			return "", fmt.Errorf("integer %s does not fit a TOML integer", node.Value)
+		}
+		return strconv.FormatInt(i, 10), nil
+	case "!!float":
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return "", err
+		}
+		switch {
+		case math.IsNaN(f):
+			return "nan", nil
+		case math.IsInf(f, 1):
+			return "inf", nil
+		case math.IsInf(f, -1):
+			return "-inf", nil
+		}
+		text := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(text, ".e") {
+			text += ".0"
+		}
+		return text, nil
+	}
+	return tomlString(node.Value), nil
+}
+
+func tomlKey(key string) string {
+	if tomlBareKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// tomlString returns s as a TOML basic string
+func tomlString(s string) string {
+	buf := &strings.Builder{}
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(buf, `\u%04X`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
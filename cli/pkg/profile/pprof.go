@@ -0,0 +1,225 @@
+package profile
+
+import (
+	"compress/gzip"
+	"io"
+	"time"
+
+	"github.com/acorn-io/aml/pkg/eval"
+)
+
+// Field numbers of the messages in profile.proto of github.com/google/pprof
+const (
+	profileSampleType    = 1
+	profileSample        = 2
+	profileLocation      = 4
+	profileFunction      = 5
+	profileStringTable   = 6
+	profileTimeNanos     = 9
+	profileDurationNanos = 10
+	profilePeriodType    = 11
+	profilePeriod        = 12
+	profileDefaultType   = 14
+
+	valueTypeType = 1
+	valueTypeUnit = 2
+
+	sampleLocationID = 1
+	sampleValue      = 2
+
+	locationID   = 1
+	locationLine = 4
+
+	lineFunctionID = 1
+	lineLine       = 2
+
+	functionID         = 1
+	functionName       = 2
+	functionSystemName = 3
+	functionFilename   = 4
+	functionStartLine  = 5
+)
+
+// WritePprof writes the samples as a gzipped profile in the protobuf format read by go tool pprof.
+// Each sample has the wall time in nanoseconds, the number of allocated objects and the number of
+// times the frame was evaluated. Frames are functions named after the path of the field, loop or
+// function and located at the position in the AML source.
+func WritePprof(w io.Writer, samples []eval.ProfileSample, start time.Time, duration time.Duration) error {
+	p := newProfileBuilder()
+
+	for _, name := range [][2]string{{"wall", "nanoseconds"}, {"alloc_objects", "count"}, {"evaluations", "count"}} {
+		var vt protoBuffer
+		vt.int64(valueTypeType, p.str(name[0]))
+		vt.int64(valueTypeUnit, p.str(name[1]))
+		p.buf.message(profileSampleType, vt)
+	}
+
+	for _, sample := range samples {
+		var ids []uint64
+		// pprof stacks start with the innermost frame
+		for i := len(sample.Stack) - 1; i >= 0; i-- {
+			ids = append(ids, p.location(sample.Stack[i]))
+		}
+		var s protoBuffer
+		s.packedUint64(sampleLocationID, ids)
+		s.packedInt64(sampleValue, []int64{int64(sample.Wall), sample.Allocs, int64(sample.Count)})
+		p.buf.message(profileSample, s)
+	}
+
+	var pt protoBuffer
+	pt.int64(valueTypeType, p.str("wall"))
+	pt.int64(valueTypeUnit, p.str("nanoseconds"))
+	p.buf.message(profilePeriodType, pt)
+	p.buf.int64(profilePeriod, 1)
+	p.buf.int64(profileTimeNanos, start.UnixNano())
+	p.buf.int64(profileDurationNanos, int64(duration))
+	p.buf.int64(profileDefaultType, p.str("wall"))
+
+	p.buf.bytes(p.locations.data)
+	p.buf.bytes(p.functions.data)
+	for _, s := range p.strings {
+		p.buf.string(profileStringTable, s)
+	}
+
+	zw := gzip.NewWriter(w)
+	if _, err := zw.Write(p.buf.data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+type functionKey struct {
+	name, filename string
+}
+
+type profileBuilder struct {
+	buf       protoBuffer
+	locations protoBuffer
+	functions protoBuffer
+
+	strings     []string
+	stringIDs   map[string]int64
+	locationIDs map[eval.ProfileFrame]uint64
+	functionIDs map[functionKey]uint64
+}
+
+func newProfileBuilder() *profileBuilder {
+	return &profileBuilder{
+		strings:     []string{""},
+		stringIDs:   map[string]int64{"": 0},
+		locationIDs: map[eval.ProfileFrame]uint64{},
+		functionIDs: map[functionKey]uint64{},
+	}
+}
+
+func (p *profileBuilder) str(s string) int64 {
+	if id, ok := p.stringIDs[s]; ok {
+		return id
+	}
+	id := int64(len(p.strings))
+	p.strings = append(p.strings, s)
+	p.stringIDs[s] = id
+	return id
+}
+
+func (p *profileBuilder) function(frame eval.ProfileFrame) uint64 {
+	key := functionKey{name: frame.Name, filename: frame.Pos.Filename}
+	if id, ok := p.functionIDs[key]; ok {
+		return id
+	}
+	id := uint64(len(p.functionIDs) + 1)
+	p.functionIDs[key] = id
+
+	var f protoBuffer
+	f.uint64(functionID, id)
+	f.int64(functionName, p.str(frame.Name))
+	f.int64(functionSystemName, p.str(frame.Name))
+	f.int64(functionFilename, p.str(frame.Pos.Filename))
+	f.int64(functionStartLine, int64(frame.Pos.Line))
+	p.functions.message(profileFunction, f)
+	return id
+}
+
+func (p *profileBuilder) location(frame eval.ProfileFrame) uint64 {
+	if id, ok := p.locationIDs[frame]; ok {
+		return id
+	}
+	id := uint64(len(p.locationIDs) + 1)
+	p.locationIDs[frame] = id
+
+	var line protoBuffer
+	line.uint64(lineFunctionID, p.function(frame))
+	line.int64(lineLine, int64(frame.Pos.Line))
+
+	var l protoBuffer
+	l.uint64(locationID, id)
+	l.message(locationLine, line)
+	p.locations.message(profileLocation, l)
+	return id
+}
+
+// protoBuffer encodes protobuf fields
+type protoBuffer struct {
+	data []byte
+}
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.data = append(b.data, byte(x)|0x80)
+		x >>= 7
+	}
+	b.data = append(b.data, byte(x))
+}
+
+func (b *protoBuffer) tag(field, wire int) {
+	b.varint(uint64(field)<<3 | uint64(wire))
+}
+
+func (b *protoBuffer) uint64(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.tag(field, wireVarint)
+	b.varint(x)
+}
+
+func (b *protoBuffer) int64(field int, x int64) {
+	b.uint64(field, uint64(x))
+}
+
+func (b *protoBuffer) string(field int, s string) {
+	b.tag(field, wireBytes)
+	b.varint(uint64(len(s)))
+	b.data = append(b.data, s...)
+}
+
+func (b *protoBuffer) message(field int, m protoBuffer) {
+	b.tag(field, wireBytes)
+	b.varint(uint64(len(m.data)))
+	b.data = append(b.data, m.data...)
+}
+
+func (b *protoBuffer) bytes(data []byte) {
+	b.data = append(b.data, data...)
+}
+
+func (b *protoBuffer) packedUint64(field int, values []uint64) {
+	var packed protoBuffer
+	for _, v := range values {
+		packed.varint(v)
+	}
+	b.message(field, packed)
+}
+
+func (b *protoBuffer) packedInt64(field int, values []int64) {
+	var packed protoBuffer
+	for _, v := range values {
+		packed.varint(uint64(v))
+	}
+	b.message(field, packed)
+}
@@ -0,0 +1,49 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/acorn-io/aml/pkg/eval"
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	field = eval.ProfileFrame{Name: "values", Pos: eval.Position{Filename: "test.acorn", Line: 5, Column: 1}}
+	loop  = eval.ProfileFrame{Name: "values[for]", Pos: eval.Position{Filename: "test.acorn", Line: 5, Column: 14}}
+	call  = eval.ProfileFrame{Name: "double()", Pos: eval.Position{Filename: "test.acorn", Line: 1, Column: 13}}
+
+	samples = []eval.ProfileSample{
+		{Stack: []eval.ProfileFrame{field}, Wall: time.Millisecond, Allocs: 10, Count: 1},
+		{Stack: []eval.ProfileFrame{field, loop}, Wall: 2 * time.Millisecond, Allocs: 5, Count: 2},
+		{Stack: []eval.ProfileFrame{field, loop, call}, Wall: 5 * time.Millisecond, Allocs: 20, Count: 2},
+	}
+)
+
+func TestWriteText(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf, Text, samples, time.Time{}, 0))
+	autogold.ExpectFile(t, autogold.Raw(buf.String()))
+
+	err := Write(buf, "svg", samples, time.Time{}, 0)
+	autogold.Expect("unknown profile format svg, must be one of pprof, text").Equal(t, err.Error())
+}
+
+func TestWritePprof(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf, Pprof, samples, time.Unix(0, 0), 8*time.Millisecond))
+
+	r, err := gzip.NewReader(buf)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	for _, s := range []string{"wall", "nanoseconds", "alloc_objects", "values[for]", "double()", "test.acorn"} {
+		require.Contains(t, string(data), s)
+	}
+	autogold.Expect(int(238)).Equal(t, len(data))
+}
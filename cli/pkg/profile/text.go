@@ -0,0 +1,110 @@
+package profile
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/acorn-io/aml/pkg/eval"
+)
+
+const (
+	Pprof = "pprof"
+	Text  = "text"
+)
+
+var Formats = []string{Pprof, Text}
+
+// Write writes the samples in the given format
+func Write(w io.Writer, format string, samples []eval.ProfileSample, start time.Time, duration time.Duration) error {
+	switch format {
+	case Pprof:
+		return WritePprof(w, samples, start, duration)
+	case Text:
+		return WriteText(w, samples)
+	}
+	return fmt.Errorf("unknown profile format %s, must be one of %s", format, strings.Join(Formats, ", "))
+}
+
+type frameStats struct {
+	frame  eval.ProfileFrame
+	flat   time.Duration
+	cum    time.Duration
+	allocs int64
+	count  int
+}
+
+// WriteText writes a line for each frame with the time spent in the frame itself (flat), the time
+// including the frames called from it (cum), the objects allocated in the frame itself and the
+// number of times it was evaluated. Frames are sorted by flat time.
+func WriteText(w io.Writer, samples []eval.ProfileSample) error {
+	var (
+		total  time.Duration
+		allocs int64
+		stats  = map[eval.ProfileFrame]*frameStats{}
+	)
+
+	get := func(frame eval.ProfileFrame) *frameStats {
+		s, ok := stats[frame]
+		if !ok {
+			s = &frameStats{frame: frame}
+			stats[frame] = s
+		}
+		return s
+	}
+
+	for _, sample := range samples {
+		if len(sample.Stack) == 0 {
+			continue
+		}
+		total += sample.Wall
+		allocs += sample.Allocs
+
+		leaf := get(sample.Stack[len(sample.Stack)-1])
+		leaf.flat += sample.Wall
+		leaf.allocs += sample.Allocs
+		leaf.count += sample.Count
+
+		// recursive frames are only counted once per stack
+		seen := map[eval.ProfileFrame]bool{}
+		for _, frame := range sample.Stack {
+			if !seen[frame] {
+				seen[frame] = true
+				get(frame).cum += sample.Wall
+			}
+		}
+	}
+
+	sorted := make([]*frameStats, 0, len(stats))
+	for _, s := range stats {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].flat != sorted[j].flat {
+			return sorted[i].flat > sorted[j].flat
+		}
+		if sorted[i].cum != sorted[j].cum {
+			return sorted[i].cum > sorted[j].cum
+		}
+		return sorted[i].frame.Pos.String() < sorted[j].frame.Pos.String()
+	})
+
+	fmt.Fprintf(w, "total: %s, %d objects allocated\n", total, allocs)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "flat\tflat%%\tcum\tcum%%\tallocs\tevaluations\t\n")
+	for _, s := range sorted {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%d\t  %s %s\n", s.flat, percent(s.flat, total), s.cum,
+			percent(s.cum, total), s.allocs, s.count, s.frame.Name, s.frame.Pos)
+	}
+	return tw.Flush()
+}
+
+func percent(d, total time.Duration) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(d)*100/float64(total))
+}
@@ -0,0 +1,12 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package prompt
+
+import "io"
+
+// readHidden calls read as is, turning off the echo of the terminal is not supported on this
+// platform
+func readHidden(_ io.Reader, read func() (string, error)) (string, bool, error) {
+	s, err := read()
+	return s, false, err
+}
@@ -0,0 +1,46 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package prompt
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// readHidden calls read with the echo of the terminal turned off. The returned bool is false if
+// in is not a terminal, in which case read is called as is.
+func readHidden(in io.Reader, read func() (string, error)) (string, bool, error) {
+	f, ok := in.(*os.File)
+	if !ok {
+		s, err := read()
+		return s, false, err
+	}
+
+	var old syscall.Termios
+	if err := ioctlTermios(f.Fd(), ioctlGetTermios, &old); err != nil {
+		s, err := read()
+		return s, false, err
+	}
+
+	hidden := old
+	hidden.Lflag &^= syscall.ECHO
+	hidden.Lflag |= syscall.ICANON | syscall.ISIG
+	hidden.Iflag |= syscall.ICRNL
+	if err := ioctlTermios(f.Fd(), ioctlSetTermios, &hidden); err != nil {
+		return "", false, err
+	}
+	defer ioctlTermios(f.Fd(), ioctlSetTermios, &old)
+
+	s, err := read()
+	return s, true, err
+}
+
+func ioctlTermios(fd uintptr, request uintptr, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
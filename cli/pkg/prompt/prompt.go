@@ -0,0 +1,135 @@
+package prompt
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/acorn-io/aml/cli/pkg/flagargs"
+	"github.com/acorn-io/aml/pkg/schema"
+)
+
+// Args asks for the value of each field, writing the questions to out and reading the answers
+// from in one line at a time. Answers are parsed and validated with flags, an invalid answer is
+// reported and the field is asked again. The answers for sensitive args are read without echo
+// and are not printed back.
+func Args(in io.Reader, out io.Writer, flags *flagargs.Flags, fields []schema.Field) (map[string]any, error) {
+	var (
+		reader = bufio.NewReader(in)
+		result = map[string]any{}
+	)
+
+	for _, field := range fields {
+		values := flags.Values(field.Name)
+		describe(out, field, values)
+		for {
+			fmt.Fprintf(out, "%s: ", field.Name)
+			answer, hidden, err := readAnswer(in, reader, field.Type.Sensitive)
+			if hidden {
+				// the newline typed is not echoed either
+				fmt.Fprintln(out)
+			}
+			if err == io.EOF && answer == "" {
+				fmt.Fprintln(out)
+				return nil, fmt.Errorf("no value given for arg %s", field.Name)
+			} else if err != nil && err != io.EOF {
+				return nil, err
+			}
+
+			answer = strings.TrimSpace(answer)
+			val, err := parse(flags, field, answer)
+			if err != nil && field.Type.Sensitive && answer != "" {
+				fmt.Fprintln(out, "invalid value")
+				continue
+			} else if err != nil && len(values) > 0 && answer != "" {
+				fmt.Fprintf(out, "invalid value: must be one of %s\n", strings.Join(values, ", "))
+				continue
+			} else if err != nil {
+				fmt.Fprintf(out, "invalid value: %v\n", err)
+				continue
+			}
+			result[field.Name] = val
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// readAnswer reads a line from reader, with the echo of in turned off if sensitive is set. The
+// returned bool is true if the echo was turned off.
+func readAnswer(in io.Reader, reader *bufio.Reader, sensitive bool) (string, bool, error) {
+	if !sensitive {
+		answer, err := reader.ReadString('\n')
+		return answer, false, err
+	}
+	return readHidden(in, func() (string, error) {
+		return reader.ReadString('\n')
+	})
+}
+
+func parse(flags *flagargs.Flags, field schema.Field, answer string) (any, error) {
+	if answer == "" {
+		return nil, fmt.Errorf("a value is required")
+	}
+
+	val, err := flags.ParseValue(field.Name, answer)
+	if err != nil {
+		return nil, err
+	}
+	return val, flags.Validate(field.Name, val)
+}
+
+func describe(out io.Writer, field schema.Field, values []string) {
+	if field.Description == "" {
+		fmt.Fprintln(out, field.Name)
+	} else {
+		fmt.Fprintf(out, "%s - %s\n", field.Name, strings.ReplaceAll(field.Description, "\n", " "))
+	}
+	fmt.Fprintf(out, "  type: %s\n", typeName(field.Type, len(values) > 0))
+	if len(values) > 0 {
+		fmt.Fprintf(out, "  allowed values: %s\n", strings.Join(values, ", "))
+	}
+}
+
+// typeName returns the kind of the field, the constraints of the field and the format the value
+// is entered in. The constraints are left out for fields with a fixed set of values as the values
+// are listed instead.
+func typeName(fieldType schema.FieldType, enum bool) string {
+	var parts []string
+	if !enum {
+		for _, constraint := range fieldType.Constraint {
+			if constraint.Left == nil && constraint.Right != nil {
+				parts = append(parts, constraint.Op+" "+toJSON(constraint.Right))
+			}
+		}
+	}
+
+	result := string(fieldType.Kind)
+	if fieldType.Kind == schema.ArrayKind && fieldType.Array != nil && fieldType.Array.Items.Kind != "" {
+		result = "array of " + string(fieldType.Array.Items.Kind)
+	}
+	if len(parts) > 0 {
+		result += " " + strings.Join(parts, ", ")
+	}
+
+	switch fieldType.Kind {
+	case schema.BoolKind:
+		result += " (yes or no)"
+	case schema.ArrayKind:
+		result += " (comma separated)"
+	case schema.ObjectKind:
+		result += " (@{...} or @FILE)"
+	}
+	return result
+}
+
+func toJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
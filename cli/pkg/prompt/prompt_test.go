@@ -0,0 +1,54 @@
+package prompt
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/acorn-io/aml/cli/pkg/flagargs"
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArgs(t *testing.T) {
+	flags, err := flagargs.Load("testdata/TestArgs/input.acorn", flagargs.Options{})
+	require.NoError(t, err)
+
+	args, profiles, err := flags.Parse(nil)
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	answers, err := Args(strings.NewReader("app\n\n0\n2\nwarn\ninfo\nmaybe\nno\n"), out, flags, flags.Missing(args, profiles))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(answers)
+	require.NoError(t, err)
+	autogold.Expect(`{"debug":false,"level":"info","name":"app","replicas":2}`).Equal(t, string(data))
+	autogold.ExpectFile(t, autogold.Raw(out.String()))
+}
+
+func TestArgsEOF(t *testing.T) {
+	flags, err := flagargs.Load("testdata/TestArgs/input.acorn", flagargs.Options{})
+	require.NoError(t, err)
+
+	_, err = Args(strings.NewReader("app\n"), &bytes.Buffer{}, flags, flags.Missing(nil, nil))
+	autogold.Expect("no value given for arg replicas").Equal(t, err.Error())
+}
+
+func TestArgsSensitive(t *testing.T) {
+	flags, err := flagargs.Load("testdata/TestArgsSensitive/input.acorn", flagargs.Options{})
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	answers, err := Args(strings.NewReader("Hunter2\nhunter\n"), out, flags, flags.Missing(nil, nil))
+	require.NoError(t, err)
+
+	data, err := json.Marshal(answers)
+	require.NoError(t, err)
+	autogold.Expect(`{"password":"hunter"}`).Equal(t, string(data))
+	autogold.Expect(`password - Database password
+  type: string =~ "(redacted)"
+password: invalid value
+password: `).Equal(t, out.String())
+}
@@ -0,0 +1,267 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/acorn-io/aml"
+	"github.com/acorn-io/aml/pkg/eval"
+	"github.com/acorn-io/aml/pkg/schema"
+	"github.com/acorn-io/aml/pkg/value"
+)
+
+const (
+	DefaultTimeout         = 10 * time.Second
+	DefaultMaxRequestBytes = 1 << 20
+)
+
+type Options struct {
+	// Timeout is the default and maximum duration of an evaluation
+	Timeout time.Duration
+	// MaxDepth is the default and maximum depth of nested scopes and function calls
+	MaxDepth int
+	// MaxRequestBytes is the maximum size of a request body
+	MaxRequestBytes int64
+}
+
+func (o Options) complete() Options {
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+	if o.MaxDepth <= 0 || o.MaxDepth > eval.MaxCallDepth {
+		o.MaxDepth = eval.MaxCallDepth
+	}
+	if o.MaxRequestBytes <= 0 {
+		o.MaxRequestBytes = DefaultMaxRequestBytes
+	}
+	return o
+}
+
+// Limits can be set on a request to lower the limits of the server for that request
+type Limits struct {
+	// Timeout is a duration such as "500ms" or "2s"
+	Timeout  string `json:"timeout,omitempty"`
+	MaxDepth int    `json:"maxDepth,omitempty"`
+}
+
+type Request struct {
+	Source string `json:"source"`
+	// SourceName is the file name used in error messages
+	SourceName string         `json:"sourceName,omitempty"`
+	Args       map[string]any `json:"args,omitempty"`
+	Profiles   []string       `json:"profiles,omitempty"`
+	// Schema is the source of the schema the result is validated against
+	Schema string `json:"schema,omitempty"`
+	Limits Limits `json:"limits,omitempty"`
+}
+
+type FormatResponse struct {
+	Source string `json:"source"`
+}
+
+type ValidateResponse struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+type requestError struct {
+	status int
+	err    error
+}
+
+func (r *requestError) Error() string {
+	return r.err.Error()
+}
+
+func badRequest(format string, args ...any) error {
+	return &requestError{
+		status: http.StatusBadRequest,
+		err:    fmt.Errorf(format, args...),
+	}
+}
+
+type server struct {
+	opts Options
+}
+
+// NewHandler returns a handler that serves the following endpoints. All endpoints accept a POST
+// of a Request.
+//
+//	/v1/eval      evaluates the source and returns the result
+//	/v1/args      returns the description of the args of the source (schema.File)
+//	/v1/schema    evaluates the source as a schema and returns its summary (schema.Summary)
+//	/v1/format    returns the formatted source as a FormatResponse
+//	/v1/validate  validates the result of the source against the schema and returns a ValidateResponse
+func NewHandler(opts Options) http.Handler {
+	s := &server{
+		opts: opts.complete(),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/eval", s.handle(s.eval))
+	mux.Handle("/v1/args", s.handle(s.args))
+	mux.Handle("/v1/schema", s.handle(s.schema))
+	mux.Handle("/v1/format", s.handle(s.format))
+	mux.Handle("/v1/validate", s.handle(s.validate))
+	return mux
+}
+
+func (s *server) handle(handler func(ctx context.Context, req *Request) (any, error)) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			rw.Header().Set("Allow", http.MethodPost)
+			writeJSON(rw, http.StatusMethodNotAllowed, ErrorResponse{
+				Error: fmt.Sprintf("method %s is not allowed, must be POST", r.Method),
+			})
+			return
+		}
+
+		resp, err := s.serve(r, handler)
+		if err == nil {
+			writeJSON(rw, http.StatusOK, resp)
+			return
+		}
+
+		status := http.StatusUnprocessableEntity
+		if reqErr := (*requestError)(nil); errors.As(err, &reqErr) {
+			status = reqErr.status
+		} else if maxErr := (*http.MaxBytesError)(nil); errors.As(err, &maxErr) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeJSON(rw, status, ErrorResponse{
+			Error: err.Error(),
+		})
+	})
+}
+
+func (s *server) serve(r *http.Request, handler func(ctx context.Context, req *Request) (any, error)) (any, error) {
+	req := &Request{}
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, s.opts.MaxRequestBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(req); err != nil {
+		if maxErr := (*http.MaxBytesError)(nil); errors.As(err, &maxErr) {
+			return nil, err
+		}
+		return nil, badRequest("invalid request: %v", err)
+	}
+
+	ctx, cancel, err := s.context(r.Context(), req.Limits)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	return handler(ctx, req)
+}
+
+// context returns the context to evaluate a request with. The limits of the request can only
+// lower the limits of the server.
+func (s *server) context(ctx context.Context, limits Limits) (context.Context, context.CancelFunc, error) {
+	timeout := s.opts.Timeout
+	if limits.Timeout != "" {
+		d, err := time.ParseDuration(limits.Timeout)
+		if err != nil {
+			return nil, nil, badRequest("invalid timeout: %v", err)
+		} else if d <= 0 {
+			return nil, nil, badRequest("invalid timeout %s, must be greater than zero", limits.Timeout)
+		}
+		timeout = min(timeout, d)
+	}
+
+	maxDepth := s.opts.MaxDepth
+	if limits.MaxDepth < 0 {
+		return nil, nil, badRequest("invalid maxDepth %d, must be greater than zero", limits.MaxDepth)
+	} else if limits.MaxDepth > 0 {
+		maxDepth = min(maxDepth, limits.MaxDepth)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return eval.WithMaxDepth(ctx, maxDepth), cancel, nil
+}
+
+func (req *Request) decoderOption(ctx context.Context) aml.DecoderOption {
+	opt := aml.DecoderOption{
+		SourceName: req.SourceName,
+		Args:       req.Args,
+		Profiles:   req.Profiles,
+		Context:    ctx,
+	}
+	if req.Schema != "" {
+		opt.Schema = strings.NewReader(req.Schema)
+		opt.SchemaSourceName = "<schema>"
+	}
+	return opt
+}
+
+func (s *server) eval(ctx context.Context, req *Request) (any, error) {
+	var out value.Value
+	if err := aml.Unmarshal([]byte(req.Source), &out, req.decoderOption(ctx)); err != nil {
+		return nil, err
+	}
+	if !value.RevealSecrets {
+		out = value.Redact(out)
+	}
+	result, ok, err := value.OrderedNativeValue(out)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, fmt.Errorf("value kind %s can not be converted to JSON", out.Kind())
+	}
+	return result, nil
+}
+
+func (s *server) args(ctx context.Context, req *Request) (any, error) {
+	out := &schema.File{}
+	return out, aml.Unmarshal([]byte(req.Source), out, req.decoderOption(ctx))
+}
+
+func (s *server) schema(ctx context.Context, req *Request) (any, error) {
+	out := &schema.Summary{}
+	return out, aml.Unmarshal([]byte(req.Source), out, req.decoderOption(ctx))
+}
+
+func (s *server) format(_ context.Context, req *Request) (any, error) {
+	out, err := aml.Format([]byte(req.Source))
+	if err != nil {
+		return nil, err
+	}
+	return FormatResponse{
+		Source: string(out),
+	}, nil
+}
+
+func (s *server) validate(ctx context.Context, req *Request) (any, error) {
+	if req.Schema == "" {
+		return nil, badRequest("schema is required")
+	}
+
+	var out value.Value
+	if err := aml.Unmarshal([]byte(req.Source), &out, req.decoderOption(ctx)); err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return ValidateResponse{
+			Error: err.Error(),
+		}, nil
+	}
+	return ValidateResponse{
+		Valid: true,
+	}, nil
+}
+
+func writeJSON(rw http.ResponseWriter, status int, data any) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	enc := json.NewEncoder(rw)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(data)
+}
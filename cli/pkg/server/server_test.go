@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func post(t *testing.T, opts Options, path, body string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	NewHandler(opts).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+	return rec.Code, rec.Body.String()
+}
+
+const source = `
+args: {
+	// The image tag
+	tag: "latest"
+}
+profiles: dev: tag: "dev"
+image: "nginx:\(args.tag)"
+replicas: 2
+`
+
+func quote(t *testing.T, s string) string {
+	data, err := json.Marshal(s)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestHandler(t *testing.T) {
+	source := quote(t, source)
+
+	code, body := post(t, Options{}, "/v1/eval", `{"source": `+source+`, "args": {"tag": "1.25"}}`)
+	require.Equal(t, http.StatusOK, code)
+	autogold.Expect(`{"image":"nginx:1.25","replicas":2}
+`).Equal(t, body)
+
+	code, body = post(t, Options{}, "/v1/eval", `{"source": `+source+`, "profiles": ["dev"]}`)
+	require.Equal(t, http.StatusOK, code)
+	autogold.Expect(`{"image":"nginx:dev","replicas":2}
+`).Equal(t, body)
+
+	code, body = post(t, Options{}, "/v1/args", `{"source": `+source+`}`)
+	require.Equal(t, http.StatusOK, code)
+	autogold.Expect(`{"Args":{"path":"args","fields":[{"name":"tag","description":"The image tag","type":{"kind":"string","default":"latest"}}],"allowNewKeys":true},"ProfileNames":[{"Name":"dev","Description":""}],"Profiles":{"path":"profiles","fields":[{"name":"dev","type":{"kind":"object","object":{"path":"profiles.dev","fields":[{"name":"tag","type":{"kind":"string","default":"dev"}}],"allowNewKeys":true}}}],"allowNewKeys":true}}
+`).Equal(t, body)
+
+	code, body = post(t, Options{}, "/v1/schema", `{"source": "image: string, replicas: number > 0"}`)
+	require.Equal(t, http.StatusOK, code)
+	autogold.Expect(`{"fields":[{"name":"image","type":{"kind":"string"}},{"name":"replicas","type":{"kind":"number","constraint":[{"op":">","right":0}]}}]}
+`).Equal(t, body)
+
+	code, body = post(t, Options{}, "/v1/format", `{"source": "a:   1"}`)
+	require.Equal(t, http.StatusOK, code)
+	autogold.Expect(`{"source":"a: 1\n"}
+`).Equal(t, body)
+
+	code, body = post(t, Options{}, "/v1/validate", `{"source": `+source+`, "schema": "image: string, replicas: number < 2"}`)
+	require.Equal(t, http.StatusOK, code)
+	autogold.Expect(`{"valid":false,"error":"schema violation .replicas: unmatched constraint 2 < 2"}
+`).Equal(t, body)
+
+	code, body = post(t, Options{}, "/v1/validate", `{"source": `+source+`, "schema": "replicas: number, image: string"}`)
+	require.Equal(t, http.StatusOK, code)
+	autogold.Expect(`{"valid":true}
+`).Equal(t, body)
+}
+
+func TestHandlerErrors(t *testing.T) {
+	code, body := post(t, Options{}, "/v1/eval", `{"source": "a: 1 + \"x\""}`)
+	require.Equal(t, http.StatusUnprocessableEntity, code)
+	autogold.Expect(`{"error":"can not add number to invalid kind string: <inline>:1:6"}
+`).Equal(t, body)
+
+	code, body = post(t, Options{}, "/v1/eval", `{"src": "a: 1"}`)
+	require.Equal(t, http.StatusBadRequest, code)
+	autogold.Expect(`{"error":"invalid request: json: unknown field \"src\""}
+`).Equal(t, body)
+
+	code, body = post(t, Options{}, "/v1/validate", `{"source": "a: 1"}`)
+	require.Equal(t, http.StatusBadRequest, code)
+	autogold.Expect(`{"error":"schema is required"}
+`).Equal(t, body)
+
+	code, body = post(t, Options{MaxRequestBytes: 10}, "/v1/eval", `{"source": "a: 1"}`)
+	require.Equal(t, http.StatusRequestEntityTooLarge, code)
+	autogold.Expect(`{"error":"http: request body too large"}
+`).Equal(t, body)
+
+	code, body = post(t, Options{}, "/v1/eval", `{"source": "a: 1", "limits": {"timeout": "soon"}}`)
+	require.Equal(t, http.StatusBadRequest, code)
+	autogold.Expect(`{"error":"invalid timeout: time: invalid duration \"soon\""}
+`).Equal(t, body)
+
+	rec := httptest.NewRecorder()
+	NewHandler(Options{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/eval", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	autogold.Expect(`{"error":"method GET is not allowed, must be POST"}
+`).Equal(t, rec.Body.String())
+}
+
+func TestHandlerLimits(t *testing.T) {
+	source := quote(t, "let f: function { args: n: number, return: [if args.n > 0 { f(args.n - 1) }, args.n] }\nout: f(5)")
+
+	code, _ := post(t, Options{}, "/v1/eval", `{"source": `+source+`}`)
+	require.Equal(t, http.StatusOK, code)
+
+	code, body := post(t, Options{}, "/v1/eval", `{"source": `+source+`, "limits": {"maxDepth": 10}}`)
+	require.Equal(t, http.StatusUnprocessableEntity, code)
+	autogold.Expect(`{"error":"invalid arguments: key not found \"number\": exceeded max scope depth 12 > 10: <inline>:1:28 (<inline>:1:25): <inline>:1:8 (backtrace 2:7)"}
+`).Equal(t, body)
+
+	code, body = post(t, Options{MaxDepth: 10}, "/v1/eval", `{"source": `+source+`, "limits": {"maxDepth": 50}}`)
+	require.Equal(t, http.StatusUnprocessableEntity, code)
+	autogold.Expect(`{"error":"invalid arguments: key not found \"number\": exceeded max scope depth 12 > 10: <inline>:1:28 (<inline>:1:25): <inline>:1:8 (backtrace 2:7)"}
+`).Equal(t, body)
+
+	code, body = post(t, Options{}, "/v1/eval", `{"source": `+source+`, "limits": {"timeout": "1ns"}}`)
+	require.Equal(t, http.StatusUnprocessableEntity, code)
+	autogold.Expect(`{"error":"context is closed: context deadline exceeded"}
+`).Equal(t, body)
+}
@@ -0,0 +1,211 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/acorn-io/aml"
+	"github.com/acorn-io/aml/cli/pkg/diff"
+	"github.com/acorn-io/aml/cli/pkg/flagargs"
+	"github.com/acorn-io/aml/cli/pkg/output"
+	"github.com/acorn-io/aml/pkg/value"
+)
+
+const (
+	// ArgsSuffix replaces the .acorn extension of a file to name the args file it is evaluated with
+	ArgsSuffix = ".args.acorn"
+	// ProfilesSuffix replaces the .acorn extension of a file to name the file listing the profiles
+	// it is evaluated with
+	ProfilesSuffix = ".profiles"
+	// GoldenSuffix replaces the .acorn extension of a file to name the file its result is compared
+	// with
+	GoldenSuffix = ".golden"
+)
+
+type Status string
+
+const (
+	Passed  = Status("ok")
+	Failed  = Status("FAIL")
+	Updated = Status("updated")
+)
+
+type Options struct {
+	// Update writes the result of each file to its golden file instead of comparing them
+	Update bool
+	// DecryptionKey is the key used by std.decrypt in the files
+	DecryptionKey []byte
+}
+
+type Result struct {
+	File   string
+	Golden string
+	Status Status
+	// Diff describes how the result differs from the golden file when the status is Failed
+	Diff string
+}
+
+// Files returns the files to snapshot in paths. The .acorn files of a directory are returned,
+// except for args files and test files, files are returned as is.
+func Files(paths []string) (result []string, _ error) {
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			result = append(result, path)
+			continue
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, ".acorn") ||
+				strings.HasSuffix(name, ArgsSuffix) || strings.HasSuffix(name, "_test.acorn") {
+				continue
+			}
+			result = append(result, filepath.Join(path, name))
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// Run evaluates each file and compares the result with its golden file, or writes the golden
+// file if opts.Update is set. A file is evaluated with the args in its .args.acorn file and the
+// profiles listed in its .profiles file if they exist. If the evaluation fails the error is
+// compared instead of the result.
+func Run(ctx context.Context, files []string, opts Options) (result []Result, _ error) {
+	for _, file := range files {
+		r, err := runFile(ctx, file, opts)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+func sidecar(file, suffix string) string {
+	return strings.TrimSuffix(file, ".acorn") + suffix
+}
+
+func runFile(ctx context.Context, file string, opts Options) (Result, error) {
+	result := Result{
+		File:   file,
+		Golden: sidecar(file, GoldenSuffix),
+	}
+
+	rendered, err := Render(ctx, file, opts)
+	if err != nil {
+		return result, err
+	}
+
+	if opts.Update {
+		existing, err := os.ReadFile(result.Golden)
+		if err == nil && bytes.Equal(existing, rendered) {
+			result.Status = Passed
+			return result, nil
+		}
+		result.Status = Updated
+		return result, os.WriteFile(result.Golden, rendered, 0644)
+	}
+
+	golden, err := os.ReadFile(result.Golden)
+	if errors.Is(err, os.ErrNotExist) {
+		result.Status = Failed
+		result.Diff = fmt.Sprintf("golden file %s does not exist, run with --update to create it", result.Golden)
+		return result, nil
+	} else if err != nil {
+		return result, err
+	}
+
+	if bytes.Equal(golden, rendered) {
+		result.Status = Passed
+		return result, nil
+	}
+
+	result.Status = Failed
+	result.Diff, err = diffText(golden, rendered)
+	return result, err
+}
+
+// Render returns the content of the golden file of a file, which is the result in JSON or the
+// error returned by the evaluation. Errors reading the file or its sidecars are returned.
+func Render(ctx context.Context, file string, opts Options) ([]byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var flags []string
+	profiles, err := os.ReadFile(sidecar(file, ProfilesSuffix))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	for _, profile := range strings.Fields(string(profiles)) {
+		flags = append(flags, "--profile", profile)
+	}
+
+	args, activeProfiles, err := flagargs.ParseArgs(file, flags, flagargs.Options{
+		ArgsFiles: []string{sidecar(file, ArgsSuffix)},
+	})
+	if err != nil {
+		return []byte(err.Error() + "\n"), nil
+	}
+
+	var val value.Value
+	err = aml.Unmarshal(data, &val, aml.DecoderOption{
+		SourceName:    filepath.Base(file),
+		Args:          args,
+		Profiles:      activeProfiles,
+		Context:       ctx,
+		DecryptionKey: opts.DecryptionKey,
+	})
+	if err != nil {
+		return []byte(err.Error() + "\n"), nil
+	}
+
+	buf := &bytes.Buffer{}
+	if err := output.Write(buf, output.JSON, val); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// diffText describes the changes from the golden file to the new result. If both are values
+// the changes of the values are listed, otherwise the lines of both are.
+func diffText(golden, rendered []byte) (string, error) {
+	var oldValue, newValue value.Value
+	if aml.Unmarshal(golden, &oldValue) == nil && aml.Unmarshal(rendered, &newValue) == nil {
+		changes, err := diff.Values(oldValue, newValue, diff.Options{})
+		if err != nil {
+			return "", err
+		}
+		if len(changes) > 0 {
+			buf := &bytes.Buffer{}
+			if err := diff.WriteText(buf, changes); err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(buf.String()), nil
+		}
+	}
+
+	buf := &strings.Builder{}
+	for _, line := range strings.Split(strings.TrimSpace(string(golden)), "\n") {
+		buf.WriteString("- " + line + "\n")
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(rendered)), "\n") {
+		buf.WriteString("+ " + line + "\n")
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,69 @@
+package snapshot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func summary(results []Result) (result []string) {
+	for _, r := range results {
+		line := string(r.Status) + " " + filepath.Base(r.File)
+		if r.Diff != "" {
+			line += "\n" + r.Diff
+		}
+		result = append(result, line)
+	}
+	return
+}
+
+func TestRun(t *testing.T) {
+	files, err := Files([]string{"testdata/TestRun"})
+	require.NoError(t, err)
+
+	results, err := Run(context.Background(), files, Options{})
+	require.NoError(t, err)
+	autogold.Expect([]string{"ok app.acorn", `FAIL changed.acorn
+~ image: "nginx:1.24" -> "nginx:1.25"
++ ports[1]: 443`, "ok error.acorn", `FAIL missing.acorn
+golden file testdata/TestRun/missing.golden does not exist, run with --update to create it`}).Equal(t, summary(results))
+}
+
+func TestUpdate(t *testing.T) {
+	dir := t.TempDir()
+	entries, err := os.ReadDir("testdata/TestRun")
+	require.NoError(t, err)
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join("testdata/TestRun", entry.Name()))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, entry.Name()), data, 0644))
+	}
+
+	files, err := Files([]string{dir})
+	require.NoError(t, err)
+
+	results, err := Run(context.Background(), files, Options{Update: true})
+	require.NoError(t, err)
+	autogold.Expect([]string{
+		"ok app.acorn", "updated changed.acorn", "ok error.acorn",
+		"updated missing.acorn",
+	}).Equal(t, summary(results))
+
+	results, err = Run(context.Background(), files, Options{})
+	require.NoError(t, err)
+	autogold.Expect([]string{
+		"ok app.acorn", "ok changed.acorn", "ok error.acorn",
+		"ok missing.acorn",
+	}).Equal(t, summary(results))
+
+	golden, err := os.ReadFile(filepath.Join(dir, "missing.golden"))
+	require.NoError(t, err)
+	autogold.Expect(`{
+    "x": true
+}
+`).Equal(t, string(golden))
+}
@@ -0,0 +1,75 @@
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const DefaultInterval = 500 * time.Millisecond
+
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func snapshot(files []string) map[string]fileState {
+	result := make(map[string]fileState, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			result[file] = fileState{}
+			continue
+		}
+		result[file] = fileState{
+			exists:  true,
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		}
+	}
+	return result
+}
+
+// changed returns the first file that is different between the two snapshots
+func changed(files []string, before, after map[string]fileState) (string, bool) {
+	for _, file := range files {
+		if before[file] != after[file] {
+			return file, true
+		}
+	}
+	return "", false
+}
+
+// Poll calls run immediately and again each time one of the files is created, modified or
+// deleted, until the context is canceled. Errors returned by run are written to errOut and do
+// not stop polling. Changes made to the files by run itself do not trigger another run.
+func Poll(ctx context.Context, files []string, interval time.Duration, errOut io.Writer, run func() error) error {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	for {
+		if err := run(); err != nil {
+			fmt.Fprintln(errOut, err)
+		}
+
+		last := snapshot(files)
+		ticker := time.NewTicker(interval)
+		for waiting := true; waiting; {
+			select {
+			case <-ctx.Done():
+				ticker.Stop()
+				return nil
+			case <-ticker.C:
+				if file, ok := changed(files, last, snapshot(files)); ok {
+					fmt.Fprintf(errOut, "%s changed\n", file)
+					waiting = false
+				}
+			}
+		}
+		ticker.Stop()
+	}
+}
@@ -0,0 +1,63 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoll(t *testing.T) {
+	var (
+		file        = filepath.Join(t.TempDir(), "Acornfile")
+		missing     = filepath.Join(t.TempDir(), "missing")
+		errOut      = &bytes.Buffer{}
+		runs        = make(chan int)
+		ctx, cancel = context.WithCancel(context.Background())
+		count       int
+	)
+	defer cancel()
+	require.NoError(t, os.WriteFile(file, []byte("a: 1"), 0644))
+
+	done := make(chan error)
+	go func() {
+		done <- Poll(ctx, []string{file, missing}, 10*time.Millisecond, errOut, func() error {
+			count++
+			err := errors.New("failed")
+			if count != 2 {
+				// changes made by run do not trigger another run
+				err = os.WriteFile(file, []byte("a: 1 "), 0644)
+			}
+			runs <- count
+			return err
+		})
+	}()
+
+	require.Equal(t, 1, <-runs)
+	require.Equal(t, 2, writeUntilRun(t, runs, file))
+	require.Equal(t, 3, writeUntilRun(t, runs, missing))
+
+	cancel()
+	require.NoError(t, <-done)
+	require.Equal(t, file+" changed\nfailed\n"+missing+" changed\n", errOut.String())
+}
+
+// writeUntilRun keeps changing the file until the next run, a change made before Poll records
+// the state of the files after the previous run would otherwise not be seen.
+func writeUntilRun(t *testing.T, runs <-chan int, file string) int {
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for i := 0; ; i++ {
+		select {
+		case count := <-runs:
+			return count
+		case <-ticker.C:
+			require.NoError(t, os.WriteFile(file, bytes.Repeat([]byte("x"), i+10), 0644))
+		}
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
+	"strings"
 
 	"github.com/acorn-io/aml/pkg/ast"
+	"github.com/acorn-io/aml/pkg/encrypt"
 	"github.com/acorn-io/aml/pkg/eval"
 	"github.com/acorn-io/aml/pkg/parser"
 	"github.com/acorn-io/aml/pkg/schema"
@@ -22,6 +25,23 @@ type DecoderOption struct {
 	SchemaSourceName string
 	Schema           io.Reader
 	Context          context.Context
+	// Expr is an expression that is evaluated in the scope of the file and decoded instead of the
+	// file. Only the fields of the file that are referenced by the expression are evaluated.
+	Expr string
+	// Paths are dotted paths of the values to evaluate. If set the result is an object that only
+	// contains these values and only the fields needed to produce them are evaluated.
+	Paths []string
+	// DecryptionKey is the key used by std.decrypt to decrypt values
+	DecryptionKey []byte
+	// Tracer is reported the function calls, fields, schema merges, debug messages and errors of
+	// the evaluation
+	Tracer eval.Tracer
+	// LogHandler receives the messages of debug() and the warnings of the evaluation instead of
+	// the log package
+	LogHandler slog.Handler
+	// Warnings collects the warnings of the evaluation, if not set the decoder collects them and
+	// they are returned by Warnings
+	Warnings *eval.Warnings
 }
 
 func (o DecoderOption) Complete() DecoderOption {
@@ -34,6 +54,19 @@ func (o DecoderOption) Complete() DecoderOption {
 	if o.Context == nil {
 		o.Context = context.Background()
 	}
+	if len(o.DecryptionKey) > 0 {
+		o.Context = encrypt.WithKey(o.Context, o.DecryptionKey)
+	}
+	if o.Tracer != nil {
+		o.Context = eval.WithTracer(o.Context, o.Tracer)
+	}
+	if o.LogHandler != nil {
+		o.Context = eval.WithLogHandler(o.Context, o.LogHandler)
+	}
+	if o.Warnings == nil {
+		o.Warnings = eval.NewWarnings()
+	}
+	o.Context = eval.WithWarnings(o.Context, o.Warnings)
 	return o
 }
 
@@ -46,6 +79,9 @@ func (o DecoderOptions) Merge() (result DecoderOption) {
 		if opt.SourceName != "" {
 			result.SourceName = opt.SourceName
 		}
+		if opt.SchemaSourceName != "" {
+			result.SchemaSourceName = opt.SchemaSourceName
+		}
 		if opt.Context != nil {
 			result.Context = opt.Context
 		}
@@ -58,6 +94,22 @@ func (o DecoderOptions) Merge() (result DecoderOption) {
 		if opt.Schema != nil {
 			result.Schema = opt.Schema
 		}
+		if opt.Expr != "" {
+			result.Expr = opt.Expr
+		}
+		result.Paths = append(result.Paths, opt.Paths...)
+		if len(opt.DecryptionKey) > 0 {
+			result.DecryptionKey = opt.DecryptionKey
+		}
+		if opt.Tracer != nil {
+			result.Tracer = opt.Tracer
+		}
+		if opt.LogHandler != nil {
+			result.LogHandler = opt.LogHandler
+		}
+		if opt.Warnings != nil {
+			result.Warnings = opt.Warnings
+		}
 	}
 	return
 }
@@ -74,12 +126,19 @@ func NewDecoder(input io.Reader, opts ...DecoderOption) *Decoder {
 	}
 }
 
+// Warnings returns the warnings found while decoding, such as args that are deprecated or not
+// defined by the file
+func (d *Decoder) Warnings() []eval.Warning {
+	return d.opts.Warnings.List()
+}
+
 func (d *Decoder) processSchema(data value.Value) (value.Value, error) {
 	f := &eval.File{}
 
 	err := NewDecoder(d.opts.Schema, DecoderOption{
 		Context:    d.opts.Context,
 		SourceName: d.opts.SchemaSourceName,
+		Warnings:   d.opts.Warnings,
 	}).Decode(f)
 	if err != nil {
 		return nil, err
@@ -92,7 +151,44 @@ func (d *Decoder) processSchema(data value.Value) (value.Value, error) {
 		return nil, fmt.Errorf("invalid schema %s yield no schema value", d.opts.SchemaSourceName)
 	}
 
-	return value.Merge(schema, data)
+	return eval.MergeSchema(d.opts.Context, eval.Position{
+		Filename: d.opts.SchemaSourceName,
+	}, schema, data)
+}
+
+func (d *Decoder) evalQuery(file *eval.File) (value.Value, bool, error) {
+	if d.opts.Schema != nil {
+		return nil, false, fmt.Errorf("validating against a schema is not supported when evaluating an expression")
+	}
+	if len(d.opts.Paths) > 0 {
+		return nil, false, fmt.Errorf("an expression and paths can not be evaluated together")
+	}
+
+	expr, err := parser.ParseExpr("<expr>", strings.NewReader(d.opts.Expr))
+	if err != nil {
+		return nil, false, err
+	}
+
+	query, err := eval.BuildExpr(expr)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return eval.EvalQuery(d.opts.Context, file, query)
+}
+
+func (d *Decoder) evalPaths(file *eval.File) (value.Value, bool, error) {
+	if d.opts.Schema != nil {
+		return nil, false, fmt.Errorf("validating against a schema is not supported when evaluating paths")
+	}
+
+	var paths [][]string
+	for _, path := range d.opts.Paths {
+		paths = append(paths, strings.Split(path, "."))
+	}
+
+	val, err := eval.EvalPaths(d.opts.Context, file, paths...)
+	return val, err == nil, err
 }
 
 func (d *Decoder) Decode(out any) error {
@@ -146,7 +242,17 @@ func (d *Decoder) Decode(out any) error {
 		return nil
 	}
 
-	val, ok, err := eval.EvalExpr(d.opts.Context, file)
+	var (
+		val value.Value
+		ok  bool
+	)
+	if d.opts.Expr != "" {
+		val, ok, err = d.evalQuery(file)
+	} else if len(d.opts.Paths) > 0 {
+		val, ok, err = d.evalPaths(file)
+	} else {
+		val, ok, err = eval.EvalExpr(d.opts.Context, file)
+	}
 	if err != nil {
 		return err
 	} else if !ok {
@@ -166,7 +272,7 @@ func (d *Decoder) Decode(out any) error {
 		return nil
 	}
 
-	nv, ok, err := value.NativeValue(val)
+	nv, ok, err := value.OrderedNativeValue(val)
 	if err != nil {
 		return err
 	} else if !ok {
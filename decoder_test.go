@@ -1,13 +1,19 @@
 package aml
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 
+	"github.com/acorn-io/aml/pkg/encrypt"
+	"github.com/acorn-io/aml/pkg/eval"
 	"github.com/acorn-io/aml/pkg/schema"
 	"github.com/acorn-io/aml/pkg/value"
 	"github.com/hexops/autogold/v2"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 const testDocument = `
@@ -41,6 +47,106 @@ func TestUnmarshal(t *testing.T) {
 	}).Equal(t, data)
 }
 
+func TestUnmarshalOrdered(t *testing.T) {
+	data := &value.OrderedMap{}
+
+	err := Unmarshal([]byte(`
+zeta: 1
+alpha: {b: "two", a: [1, {y: true, x: null}]}
+`), data)
+	require.NoError(t, err)
+
+	autogold.Expect([]string{"zeta", "alpha"}).Equal(t, data.Keys())
+
+	out, err := json.Marshal(data)
+	require.NoError(t, err)
+	autogold.Expect(`{"zeta":1,"alpha":{"b":"two","a":[1,{"y":true,"x":null}]}}`).Equal(t, string(out))
+
+	out, err = yaml.Marshal(data)
+	require.NoError(t, err)
+	autogold.Expect(`zeta: 1
+alpha:
+    b: two
+    a:
+        - 1
+        - y: true
+          x: null
+`).Equal(t, string(out))
+}
+
+func TestUnmarshalExpr(t *testing.T) {
+	doc := []byte(`
+containers: web: image: "nginx:\(args.tag)"
+containers: db: image: "mysql"
+args: tag: "latest"
+broken: 1 + "x"
+`)
+
+	var image string
+	err := Unmarshal(doc, &image, DecoderOption{
+		Expr: "containers.web.image",
+	})
+	require.NoError(t, err)
+	autogold.Expect("nginx:latest").Equal(t, image)
+
+	var keys []string
+	err = Unmarshal(doc, &keys, DecoderOption{
+		Expr: "keys(containers)",
+	})
+	require.NoError(t, err)
+	autogold.Expect([]string{"web", "db"}).Equal(t, keys)
+
+	err = Unmarshal(doc, &keys)
+	autogold.Expect("can not add number to invalid kind string: <inline>:5:11").Equal(t, err.Error())
+}
+
+func TestUnmarshalContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var out any
+	err := Unmarshal([]byte(`x: len([1, 2])`), &out, DecoderOption{
+		Context: ctx,
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestUnmarshalDecrypt(t *testing.T) {
+	key := make([]byte, encrypt.KeySize)
+	ciphertext, err := encrypt.Encrypt(key, "hunter2")
+	require.NoError(t, err)
+
+	doc := []byte(`password: std.decrypt("` + ciphertext + `")
+masked: "\(password)"`)
+
+	out := map[string]any{}
+	err = Unmarshal(doc, &out, DecoderOption{
+		DecryptionKey: key,
+	})
+	require.NoError(t, err)
+	autogold.Expect(map[string]interface{}{"masked": "hunter2", "password": "hunter2"}).Equal(t, out)
+
+	var val value.Value
+	err = Unmarshal(doc, &val, DecoderOption{
+		DecryptionKey: key,
+	})
+	require.NoError(t, err)
+	data, err := json.Marshal(val)
+	require.NoError(t, err)
+	autogold.Expect(`{"masked":"(redacted)","password":"(redacted)"}`).Equal(t, string(data))
+
+	err = Unmarshal(doc, &out)
+	autogold.Expect("decrypt requires a key, none was provided: <inline>:1:22").Equal(t, err.Error())
+
+	otherKey := make([]byte, encrypt.KeySize)
+	otherKey[0] = 1
+	err = Unmarshal(doc, &out, DecoderOption{
+		DecryptionKey: otherKey,
+	})
+	require.NotContains(t, err.Error(), "hunter2")
+	autogold.Expect("value is encrypted with key 66687aad, the given key is 01d0fabd: <inline>:1:22").Equal(t, err.Error())
+}
+
 func TestSchemaValidate(t *testing.T) {
 	out := map[string]any{}
 	err := NewDecoder(strings.NewReader(`
@@ -97,5 +203,77 @@ func TestSchemaUnmarshal(t *testing.T) {
 			AllowNewKeys: true,
 		},
 		ProfileNames: schema.Names{schema.Name{Name: "baz"}},
+		Profiles: schema.Object{
+			Path: "profiles",
+			Fields: []schema.Field{{
+				Name: "baz",
+				Type: schema.FieldType{
+					Kind: schema.Kind("object"),
+					Object: &schema.Object{
+						Path: "profiles.baz",
+						Fields: []schema.Field{{
+							Name: "two",
+							Type: schema.FieldType{
+								Kind:    schema.Kind("number"),
+								Default: value.Number("2"),
+							},
+						}},
+						AllowNewKeys: true,
+					},
+				},
+			}},
+			AllowNewKeys: true,
+		},
 	}).Equal(t, out)
 }
+
+func TestSchemaUnmarshalSensitive(t *testing.T) {
+	out := &schema.File{}
+	err := Unmarshal([]byte(`args: password: sensitive(string) || "changeme"`), out)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(out.Args.Fields)
+	require.NoError(t, err)
+	autogold.Expect(`[{"name":"password","type":{"kind":"string","alternate":{"kind":"string","constraint":[{"op":"==","right":"(redacted)"}],"default":"(redacted)","sensitive":true},"sensitive":true}}]`).Equal(t, string(data))
+}
+
+type schemaTracer struct {
+	eval.NopTracer
+	merges []string
+}
+
+func (s *schemaTracer) SchemaMerge(_ context.Context, pos eval.Position, _, v, _ value.Value, err error) {
+	s.merges = append(s.merges, fmt.Sprintf("%s %v %v", pos, v, err))
+}
+
+func TestUnmarshalTracer(t *testing.T) {
+	tracer := &schemaTracer{}
+	out := map[string]any{}
+	err := Unmarshal([]byte(`port: 80`), &out, DecoderOption{
+		Schema:           strings.NewReader(`port: number`),
+		SchemaSourceName: "schema.acorn",
+		Tracer:           tracer,
+	})
+	require.NoError(t, err)
+	autogold.Expect([]string{"0:0 {} <nil>", "0:0 {} <nil>", `schema.acorn:0:0 {"port":80} <nil>`}).Equal(t, tracer.merges)
+}
+
+func TestDecoderWarnings(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`args: port: 80
+value: args.port`), DecoderOption{
+		SourceName: "main.acorn",
+		Args:       map[string]any{"host": "example.com"},
+	})
+
+	out := map[string]any{}
+	require.NoError(t, d.Decode(&out))
+	autogold.Expect([]eval.Warning{{
+		Pos: eval.Position{
+			Filename: "main.acorn",
+			Line:     1,
+			Column:   1,
+		},
+		Path:    "args.host",
+		Message: "arg host is not defined",
+	}}).Equal(t, d.Warnings())
+}
@@ -2,23 +2,58 @@ package aml
 
 import (
 	"bytes"
+	"encoding"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/acorn-io/aml/pkg/ast"
 	"github.com/acorn-io/aml/pkg/format"
+	"github.com/acorn-io/aml/pkg/literal"
 	"github.com/acorn-io/aml/pkg/parser"
 )
 
+type Wrap string
+
+const (
+	// WrapArgs places the encoded document in a top-level "args" field
+	WrapArgs = Wrap("args")
+	// WrapSchema places the encoded document in a top-level schema block
+	WrapSchema = Wrap("schema")
+)
+
 type EncoderOption struct {
+	// Indent is the number of spaces used for each level of indentation. If zero, tabs are used.
+	Indent int
+	// SortKeys will sort struct fields by name instead of using the order in which they are declared
+	SortKeys bool
+	// Wrap the encoded document in an args field or a schema block
+	Wrap Wrap
 }
 
 func (o EncoderOption) Complete() EncoderOption {
-	return EncoderOption{}
+	return o
 }
 
 type EncoderOptions []EncoderOption
 
 func (o EncoderOptions) Merge() (result EncoderOption) {
+	for _, opt := range o {
+		if opt.Indent != 0 {
+			result.Indent = opt.Indent
+		}
+		if opt.SortKeys {
+			result.SortKeys = true
+		}
+		if opt.Wrap != "" {
+			result.Wrap = opt.Wrap
+		}
+	}
 	return
 }
 
@@ -35,17 +70,43 @@ func NewEncoder(output io.Writer, opts ...EncoderOption) *Encoder {
 }
 
 func (d *Encoder) Encode(out any) error {
-	data, err := json.Marshal(out)
-	if err != nil {
-		return err
+	buf := &bytes.Buffer{}
+	w := &amlWriter{
+		buf:      buf,
+		sortKeys: d.opts.SortKeys,
 	}
 
-	parsed, err := parser.ParseFile("", bytes.NewReader(data))
+	switch d.opts.Wrap {
+	case "":
+		if err := w.writeDocument(reflect.ValueOf(out)); err != nil {
+			return err
+		}
+	case WrapArgs:
+		buf.WriteString("args: ")
+		if err := w.writeValue(reflect.ValueOf(out)); err != nil {
+			return err
+		}
+	case WrapSchema:
+		buf.WriteString("schema ")
+		if err := w.writeValue(reflect.ValueOf(out)); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid wrap %q, must be %q or %q", d.opts.Wrap, WrapArgs, WrapSchema)
+	}
+	buf.WriteString("\n")
+
+	parsed, err := parser.ParseFile("", buf)
 	if err != nil {
 		return err
 	}
 
-	data, err = format.Node(parsed)
+	var formatOpts []format.Option
+	if d.opts.Indent > 0 {
+		formatOpts = append(formatOpts, format.UseSpaces(d.opts.Indent), format.TabIndent(false))
+	}
+
+	data, err := format.Node(parsed, formatOpts...)
 	if err != nil {
 		return err
 	}
@@ -59,3 +120,402 @@ func Marshal(v any, opts ...EncoderOption) ([]byte, error) {
 	err := NewEncoder(buf, opts...).Encode(v)
 	return buf.Bytes(), err
 }
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// amlWriter writes Go values as AML source. The output is not expected to be pretty, it is
+// passed through the formatter afterwards.
+type amlWriter struct {
+	buf      *bytes.Buffer
+	sortKeys bool
+	depth    int
+}
+
+type encodedField struct {
+	key      string
+	comments []string
+	value    reflect.Value
+}
+
+func (w *amlWriter) writeComments(comments []string) {
+	for _, comment := range comments {
+		if comment == "" {
+			w.buf.WriteString("//\n")
+			continue
+		}
+		w.buf.WriteString("// ")
+		w.buf.WriteString(comment)
+		w.buf.WriteString("\n")
+	}
+}
+
+func (w *amlWriter) writeString(s string) {
+	if quoted, ok := literal.QuoteMulti(s, w.depth+1); ok {
+		w.buf.WriteString(quoted)
+		return
+	}
+	w.buf.WriteString(quoteString(s))
+}
+
+func (w *amlWriter) openBlock(start string) {
+	w.buf.WriteString(start)
+	w.depth++
+}
+
+func (w *amlWriter) closeBlock(end string) {
+	w.depth--
+	w.buf.WriteString(end)
+}
+
+// writeDocument writes the value as the body of a file, so objects are written without
+// surrounding braces.
+func (w *amlWriter) writeDocument(v reflect.Value) error {
+	if node, ok := toYAMLNode(v); ok {
+		return w.writeYAMLDocument(node)
+	}
+
+	fields, ok, err := w.toFields(v)
+	if err != nil {
+		return err
+	} else if !ok {
+		return w.writeValue(v)
+	}
+	return w.writeFields(fields)
+}
+
+func (w *amlWriter) writeFields(fields []encodedField) error {
+	for _, field := range fields {
+		w.writeComments(field.comments)
+		w.buf.WriteString(quoteLabel(field.key))
+		w.buf.WriteString(": ")
+		if err := w.writeValue(field.value); err != nil {
+			return fmt.Errorf("encoding field %s: %w", field.key, err)
+		}
+		w.buf.WriteString("\n")
+	}
+	return nil
+}
+
+func quoteLabel(key string) string {
+	if ast.IsValidLabel(key) {
+		return key
+	}
+	return quoteString(key)
+}
+
+func quoteString(s string) string {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSpace(buf.String())
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func isMarshaler(v reflect.Value) bool {
+	if !v.IsValid() {
+		return false
+	}
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return true
+	}
+	if v.CanAddr() {
+		t := v.Addr().Type()
+		return t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType)
+	}
+	return false
+}
+
+// toFields returns the fields of a struct or map, or false if the value is not object like.
+func (w *amlWriter) toFields(v reflect.Value) ([]encodedField, bool, error) {
+	if isMarshaler(v) {
+		return nil, false, nil
+	}
+	v = indirect(v)
+	if isMarshaler(v) {
+		return nil, false, nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return w.structFields(v), true, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, false, nil
+		}
+		fields, err := mapFields(v)
+		return fields, true, err
+	}
+	return nil, false, nil
+}
+
+func mapFields(v reflect.Value) (result []encodedField, _ error) {
+	for _, key := range v.MapKeys() {
+		var keyString string
+		switch {
+		case key.Kind() == reflect.String:
+			keyString = key.String()
+		case key.Type().Implements(textMarshalerType):
+			data, err := key.Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return nil, err
+			}
+			keyString = string(data)
+		case key.CanInt() || key.CanUint():
+			keyString = fmt.Sprint(key.Interface())
+		default:
+			return nil, fmt.Errorf("unsupported map key type %s", key.Type())
+		}
+		result = append(result, encodedField{
+			key:   keyString,
+			value: v.MapIndex(key),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].key < result[j].key
+	})
+	return result, nil
+}
+
+type fieldTag struct {
+	name      string
+	skip      bool
+	omitEmpty bool
+	comment   string
+}
+
+// parseFieldTag reads the aml struct tag, falling back to the json tag. The format of the aml tag
+// is name[,omitempty][,comment=text]. The comment option must be last and may contain commas.
+func parseFieldTag(field reflect.StructField) (result fieldTag) {
+	tag, ok := field.Tag.Lookup("aml")
+	if !ok {
+		tag = field.Tag.Get("json")
+	}
+	if tag == "-" {
+		result.skip = true
+		return
+	}
+
+	name, opts, _ := strings.Cut(tag, ",")
+	result.name = name
+	for opts != "" {
+		if comment, ok := strings.CutPrefix(opts, "comment="); ok {
+			result.comment = comment
+			break
+		}
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == "omitempty" {
+			result.omitEmpty = true
+		}
+	}
+	return
+}
+
+func (w *amlWriter) structFields(v reflect.Value) (result []encodedField) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := parseFieldTag(field)
+		if tag.skip {
+			continue
+		}
+
+		fieldValue := v.Field(i)
+		if field.Anonymous && tag.name == "" {
+			embedded := indirect(fieldValue)
+			if embedded.Kind() == reflect.Struct && !isMarshaler(embedded) {
+				result = append(result, w.structFields(embedded)...)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if tag.omitEmpty && isEmptyValue(fieldValue) {
+			continue
+		}
+
+		name := tag.name
+		if name == "" {
+			name = field.Name
+		}
+
+		var comments []string
+		if tag.comment != "" {
+			comments = strings.Split(tag.comment, "\n")
+		}
+
+		result = append(result, encodedField{
+			key:      name,
+			comments: comments,
+			value:    fieldValue,
+		})
+	}
+
+	if w.sortKeys {
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].key < result[j].key
+		})
+	}
+
+	return result
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
+
+func (w *amlWriter) writeMarshaler(v reflect.Value) error {
+	if !v.Type().Implements(jsonMarshalerType) && !v.Type().Implements(textMarshalerType) {
+		v = v.Addr()
+	}
+
+	if m, ok := v.Interface().(json.Marshaler); ok {
+		data, err := m.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var native any
+		if err := dec.Decode(&native); err != nil {
+			return err
+		}
+		return w.writeValue(reflect.ValueOf(native))
+	}
+
+	data, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return err
+	}
+	w.writeString(string(data))
+	return nil
+}
+
+func (w *amlWriter) writeValue(v reflect.Value) error {
+	if !v.IsValid() {
+		w.buf.WriteString("null")
+		return nil
+	}
+
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		w.buf.WriteString("null")
+		return nil
+	}
+
+	if node, ok := toYAMLNode(v); ok {
+		return w.writeYAMLNode(node)
+	}
+
+	if isMarshaler(v) {
+		return w.writeMarshaler(v)
+	}
+
+	fields, ok, err := w.toFields(v)
+	if err != nil {
+		return err
+	} else if ok {
+		w.openBlock("{\n")
+		if err := w.writeFields(fields); err != nil {
+			return err
+		}
+		w.closeBlock("}")
+		return nil
+	}
+
+	v = indirect(v)
+	if isMarshaler(v) {
+		return w.writeMarshaler(v)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		w.buf.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		w.buf.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		w.buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		w.buf.WriteString(strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()))
+	case reflect.String:
+		if v.Type() == reflect.TypeOf(json.Number("")) {
+			w.buf.WriteString(v.String())
+		} else {
+			w.writeString(v.String())
+		}
+	case reflect.Map:
+		// only nil maps get here, toFields handles the rest
+		w.buf.WriteString("null")
+	case reflect.Slice:
+		if v.IsNil() {
+			w.buf.WriteString("null")
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			w.buf.WriteString(quoteString(base64.StdEncoding.EncodeToString(v.Bytes())))
+			return nil
+		}
+		return w.writeArray(v)
+	case reflect.Array:
+		return w.writeArray(v)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
+
+func (w *amlWriter) writeArray(v reflect.Value) error {
+	multiline := false
+	for i := 0; i < v.Len(); i++ {
+		switch indirect(v.Index(i)).Kind() {
+		case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+			multiline = true
+		}
+	}
+
+	w.openBlock("[")
+	for i := 0; i < v.Len(); i++ {
+		if multiline {
+			w.buf.WriteString("\n")
+		} else if i > 0 {
+			w.buf.WriteString(" ")
+		}
+		if err := w.writeValue(v.Index(i)); err != nil {
+			return err
+		}
+		if multiline || i < v.Len()-1 {
+			w.buf.WriteString(",")
+		}
+	}
+	if multiline {
+		w.buf.WriteString("\n")
+	}
+	w.closeBlock("]")
+	return nil
+}
@@ -0,0 +1,170 @@
+package aml
+
+import (
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type testDatabase struct {
+	Host string `aml:"host,comment=Hostname of the database, without the port"`
+	Port int    `json:"port,omitempty"`
+}
+
+type testArgs struct {
+	Replicas int               `aml:"replicas,comment=Number of replicas"`
+	Name     string            `json:"name,omitempty"`
+	Database testDatabase      `json:"database"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Ports    []int             `json:"ports"`
+	Default  bool              `json:"default"`
+	Ignored  string            `json:"-"`
+}
+
+var testArgsValue = testArgs{
+	Replicas: 2,
+	Database: testDatabase{
+		Host: "db",
+	},
+	Labels: map[string]string{
+		"b":          "two",
+		"a":          "one",
+		"key.io/foo": "three",
+	},
+	Ports:   []int{80, 443},
+	Ignored: "ignored",
+}
+
+func TestMarshal(t *testing.T) {
+	data, err := Marshal(testArgsValue)
+	require.NoError(t, err)
+
+	autogold.Expect(`// Number of replicas
+replicas: 2
+database: {
+	// Hostname of the database, without the port
+	host: "db"
+}
+labels: {
+	a:            "one"
+	b:            "two"
+	"key.io/foo": "three"
+}
+ports: [80, 443]
+"default": false
+`).Equal(t, string(data))
+
+	out := map[string]any{}
+	err = Unmarshal(data, &out)
+	require.NoError(t, err)
+
+	autogold.Expect(map[string]interface{}{
+		"database": map[string]interface{}{"host": "db"},
+		"default":  false,
+		"labels": map[string]interface{}{
+			"a":          "one",
+			"b":          "two",
+			"key.io/foo": "three",
+		},
+		"ports":    []interface{}{80, 443},
+		"replicas": 2,
+	}).Equal(t, out)
+}
+
+func TestMarshalOptions(t *testing.T) {
+	data, err := Marshal(testArgsValue, EncoderOption{
+		Indent:   2,
+		SortKeys: true,
+		Wrap:     WrapArgs,
+	})
+	require.NoError(t, err)
+
+	autogold.Expect(`args: {
+  database: {
+    // Hostname of the database, without the port
+    host: "db"
+  }
+  "default": false
+  labels: {
+    a:            "one"
+    b:            "two"
+    "key.io/foo": "three"
+  }
+  ports: [80, 443]
+  // Number of replicas
+  replicas: 2
+}
+`).Equal(t, string(data))
+
+	data, err = Marshal(testDatabase{Host: "localhost"}, EncoderOption{
+		Wrap: WrapSchema,
+	})
+	require.NoError(t, err)
+
+	autogold.Expect(`schema {
+	// Hostname of the database, without the port
+	host: "localhost"
+}
+`).Equal(t, string(data))
+}
+
+func TestMarshalYAML(t *testing.T) {
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte(`# Service definition
+
+# Number of replicas
+replicas: 2 # at least one
+defaults: &defaults
+  image: nginx
+  "if": true
+service:
+  <<: *defaults
+  image: nginx:latest
+  script: |
+    echo hello
+    echo world
+ports: # exposed ports
+- 80
+# secure
+- 443
+`), &node)
+	require.NoError(t, err)
+
+	data, err := Marshal(&node)
+	require.NoError(t, err)
+
+	autogold.Expect(`// Service definition
+
+// Number of replicas
+replicas: 2 // at least one
+defaults: {
+	image: "nginx"
+	"if":  true
+}
+service: {
+	image: "nginx:latest"
+	"if":  true
+	script: """
+		echo hello
+		echo world
+		
+		"""
+}
+// exposed ports
+ports: [
+	80,
+	// secure
+	443,
+]
+`).Equal(t, string(data))
+
+	var out struct {
+		Service struct {
+			Script string `json:"script"`
+		} `json:"service"`
+	}
+	require.NoError(t, Unmarshal(data, &out))
+	autogold.Expect("echo hello\necho world\n").Equal(t, out.Service.Script)
+}
@@ -0,0 +1,290 @@
+package aml
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	yamlNodeType = reflect.TypeOf(yaml.Node{})
+	decimalInt   = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+)
+
+// toYAMLNode returns the value as a *yaml.Node if it is a yaml.Node or a pointer to one. YAML
+// nodes are encoded from their syntax tree so that comments and key order are preserved.
+func toYAMLNode(v reflect.Value) (*yaml.Node, bool) {
+	for v.IsValid() && v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() || !v.CanInterface() {
+		return nil, false
+	}
+	switch {
+	case v.Type() == yamlNodeType:
+		if v.CanAddr() {
+			return v.Addr().Interface().(*yaml.Node), true
+		}
+		node := v.Interface().(yaml.Node)
+		return &node, true
+	case v.Kind() == reflect.Pointer && v.Type().Elem() == yamlNodeType && !v.IsNil():
+		return v.Interface().(*yaml.Node), true
+	}
+	return nil, false
+}
+
+// yamlComments converts a YAML comment, which may span multiple lines, to the text of line comments.
+func yamlComments(comment string) (result []string) {
+	if comment == "" {
+		return nil
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "#")
+		line = strings.TrimPrefix(line, " ")
+		result = append(result, strings.TrimRight(line, " \t"))
+	}
+	return
+}
+
+func (w *amlWriter) writeLineComment(comment string) {
+	for _, line := range yamlComments(comment) {
+		if line == "" {
+			continue
+		}
+		w.buf.WriteString(" // ")
+		w.buf.WriteString(line)
+	}
+}
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+func isYAMLComposite(node *yaml.Node) bool {
+	node = resolveAlias(node)
+	return node != nil && (node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode)
+}
+
+func (w *amlWriter) writeYAMLDocument(node *yaml.Node) error {
+	if node.Kind != yaml.DocumentNode {
+		if node.Kind != yaml.MappingNode {
+			return w.writeYAMLNode(node)
+		}
+		return w.writeYAMLFields(node)
+	}
+
+	if node.HeadComment != "" {
+		// a document comment is separated from the first field so it is not attached to it
+		w.writeComments(yamlComments(node.HeadComment))
+		w.buf.WriteString("\n")
+	}
+	if len(node.Content) > 0 {
+		content := node.Content[0]
+		if content.Kind == yaml.MappingNode {
+			w.writeComments(yamlComments(content.HeadComment))
+			if err := w.writeYAMLFields(content); err != nil {
+				return err
+			}
+			w.writeComments(yamlComments(content.FootComment))
+		} else if err := w.writeYAMLNode(content); err != nil {
+			return err
+		}
+	}
+	w.writeComments(yamlComments(node.FootComment))
+	return nil
+}
+
+type yamlField struct {
+	key   *yaml.Node
+	value *yaml.Node
+}
+
+func isYAMLMergeKey(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!merge"
+}
+
+// yamlMappingFields returns the key value pairs of the mapping with merge keys expanded. Keys
+// that are explicitly set take precedence over merged keys and keys from earlier merges take
+// precedence over later ones.
+func yamlMappingFields(node *yaml.Node) (result []yamlField, _ error) {
+	index := map[string]int{}
+	add := func(field yamlField, override bool) {
+		if i, ok := index[field.key.Value]; ok {
+			if override {
+				result[i] = field
+			}
+			return
+		}
+		index[field.key.Value] = len(result)
+		result = append(result, field)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := resolveAlias(node.Content[i]), node.Content[i+1]
+		if key.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("line %d: only scalar keys are supported", key.Line)
+		}
+		if !isYAMLMergeKey(key) {
+			add(yamlField{key: key, value: value}, true)
+			continue
+		}
+
+		merges := []*yaml.Node{resolveAlias(value)}
+		if merges[0].Kind == yaml.SequenceNode {
+			merges = merges[0].Content
+		}
+		for _, merge := range merges {
+			merge = resolveAlias(merge)
+			if merge.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("line %d: merge value must be a mapping", merge.Line)
+			}
+			fields, err := yamlMappingFields(merge)
+			if err != nil {
+				return nil, err
+			}
+			for _, field := range fields {
+				add(field, false)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (w *amlWriter) writeYAMLFields(node *yaml.Node) error {
+	fields, err := yamlMappingFields(node)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		value := resolveAlias(field.value)
+		w.writeComments(yamlComments(field.key.HeadComment))
+		w.writeComments(yamlComments(field.value.HeadComment))
+		lineComment := strings.TrimSpace(field.key.LineComment + "\n" + field.value.LineComment)
+		if isYAMLComposite(value) {
+			// a line comment can not follow an opening brace, so it is written above the field
+			w.writeComments(yamlComments(lineComment))
+			lineComment = ""
+		}
+
+		w.buf.WriteString(quoteLabel(field.key.Value))
+		w.buf.WriteString(": ")
+		if err := w.writeYAMLNode(field.value); err != nil {
+			return fmt.Errorf("encoding field %s: %w", field.key.Value, err)
+		}
+		w.writeLineComment(lineComment)
+		w.buf.WriteString("\n")
+		w.writeComments(yamlComments(field.key.FootComment))
+		w.writeComments(yamlComments(field.value.FootComment))
+	}
+	return nil
+}
+
+func (w *amlWriter) writeYAMLSequence(node *yaml.Node) error {
+	multiline := false
+	for _, item := range node.Content {
+		if isYAMLComposite(item) || item.HeadComment != "" || item.LineComment != "" || item.FootComment != "" {
+			multiline = true
+		}
+	}
+
+	w.openBlock("[")
+	for i, item := range node.Content {
+		if multiline {
+			w.buf.WriteString("\n")
+			w.writeComments(yamlComments(item.HeadComment))
+		} else if i > 0 {
+			w.buf.WriteString(" ")
+		}
+		if err := w.writeYAMLNode(item); err != nil {
+			return err
+		}
+		if multiline || i < len(node.Content)-1 {
+			w.buf.WriteString(",")
+		}
+		if multiline {
+			w.writeLineComment(item.LineComment)
+			if item.FootComment != "" {
+				w.buf.WriteString("\n")
+				w.writeComments(yamlComments(item.FootComment))
+			}
+		}
+	}
+	if multiline {
+		w.buf.WriteString("\n")
+	}
+	w.closeBlock("]")
+	return nil
+}
+
+func (w *amlWriter) writeYAMLNode(node *yaml.Node) error {
+	node = resolveAlias(node)
+	if node == nil {
+		w.buf.WriteString("null")
+		return nil
+	}
+
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			w.buf.WriteString("null")
+			return nil
+		}
+		return w.writeYAMLNode(node.Content[0])
+	case yaml.MappingNode:
+		w.openBlock("{\n")
+		if err := w.writeYAMLFields(node); err != nil {
+			return err
+		}
+		w.closeBlock("}")
+		return nil
+	case yaml.SequenceNode:
+		return w.writeYAMLSequence(node)
+	case yaml.ScalarNode:
+		return w.writeYAMLScalar(node)
+	}
+	return fmt.Errorf("line %d: unsupported YAML node kind %d", node.Line, node.Kind)
+}
+
+func (w *amlWriter) writeYAMLScalar(node *yaml.Node) error {
+	switch node.ShortTag() {
+	case "!!str", "!!timestamp":
+		w.writeString(node.Value)
+	case "!!binary":
+		w.writeString(strings.Join(strings.Fields(node.Value), ""))
+	case "!!null":
+		w.buf.WriteString("null")
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return err
+		}
+		w.buf.WriteString(strconv.FormatBool(b))
+	case "!!int", "!!float":
+		if node.ShortTag() == "!!int" && decimalInt.MatchString(node.Value) {
+			// written as is so that integers that do not fit an int64 are kept exactly
+			w.buf.WriteString(node.Value)
+			return nil
+		}
+		var n any
+		if err := node.Decode(&n); err != nil {
+			return err
+		}
+		if f, ok := n.(float64); ok && (math.IsInf(f, 0) || math.IsNaN(f)) {
+			return fmt.Errorf("line %d: %s can not be represented as a number", node.Line, node.Value)
+		}
+		return w.writeValue(reflect.ValueOf(n))
+	default:
+		return fmt.Errorf("line %d: unsupported YAML tag %s", node.Line, node.Tag)
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package ast
 import (
 	"strings"
 	"unicode/utf8"
+
+	"github.com/acorn-io/aml/pkg/token"
 )
 
 func isAllowedCharacter(ch rune) bool {
@@ -13,6 +15,11 @@ func isAllowedDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
+// IsValidLabel reports whether str can be used as a field label without being quoted.
+func IsValidLabel(label string) bool {
+	return IsValidIdent(label) && label != "string" && !token.Lookup(label).IsKeyword()
+}
+
 // IsValidIdent reports whether str is a valid identifier.
 func IsValidIdent(ident string) bool {
 	if ident == "" {
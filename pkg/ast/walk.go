@@ -91,6 +91,9 @@ func walk(v visitor, node Node) {
 	case *StructLit:
 		walkDeclList(v, n.Elts)
 
+	case *SchemaLit:
+		walk(v, n.Struct)
+
 	// Expressions
 	case *BadExpr, *Ident, *BasicLit:
 		// nothing to do
@@ -103,6 +106,10 @@ func walk(v visitor, node Node) {
 	case *ListLit:
 		walkExprList(v, n.Elts)
 
+	case *ListComprehension:
+		walk(v, n.Clause)
+		walk(v, n.Value)
+
 	case *ParenExpr:
 		walk(v, n.X)
 
@@ -130,6 +137,9 @@ func walk(v visitor, node Node) {
 	case *UnaryExpr:
 		walk(v, n.X)
 
+	case *DefaultExpr:
+		walk(v, n.X)
+
 	case *BinaryExpr:
 		walk(v, n.X)
 		walk(v, n.Y)
@@ -0,0 +1,44 @@
+package ast_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/acorn-io/aml/pkg/ast"
+	"github.com/acorn-io/aml/pkg/parser"
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalk(t *testing.T) {
+	f, err := parser.ParseFile("test.acorn", strings.NewReader(`args: s: schema {
+	name: string
+}
+list: [for i in [1] { a }]
+d: default x
+`))
+	require.NoError(t, err)
+
+	var nodes []string
+	ast.Walk(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			nodes = append(nodes, "Ident "+n.Name)
+		case *ast.SchemaLit, *ast.ListComprehension, *ast.DefaultExpr:
+			nodes = append(nodes, fmt.Sprintf("%T", n))
+		}
+		return true
+	}, nil)
+	autogold.Expect([]string{
+		"Ident args", "Ident s", "*ast.SchemaLit", "Ident name",
+		"Ident string",
+		"Ident list",
+		"*ast.ListComprehension",
+		"Ident i",
+		"Ident a",
+		"Ident d",
+		"*ast.DefaultExpr",
+		"Ident x",
+	}).Equal(t, nodes)
+}
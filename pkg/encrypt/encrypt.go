@@ -0,0 +1,149 @@
+package encrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// Prefix starts every encrypted value, it is followed by the id of the key and the ciphertext
+	Prefix = "aml:v1:"
+	// KeySize is the size in bytes of an AES-256 key
+	KeySize = 32
+)
+
+// GenerateKey returns a new random key
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// WriteKeyFile writes a new random key to path. The file must not already exist.
+func WriteKeyFile(path string) ([]byte, error) {
+	key, err := GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(base64.StdEncoding.EncodeToString(key) + "\n"); err != nil {
+		return nil, err
+	}
+	return key, f.Close()
+}
+
+// ReadKeyFile reads a base64 encoded key from path
+func ReadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// the content of the file is never included in errors
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key file %s: key is not base64 encoded", path)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key file %s: key must be %d bytes, got %d", path, KeySize, len(key))
+	}
+	return key, nil
+}
+
+// KeyID returns a short identifier of a key that is stored with the values it encrypts
+func KeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// IsEncrypted returns true if s has the format of an encrypted value
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, Prefix)
+}
+
+// Encrypt encrypts plaintext with AES-256-GCM. The result is prefixed with Prefix and the id of
+// the key.
+func Encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return Prefix + KeyID(key) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value returned by Encrypt. Errors never include the ciphertext, the plaintext
+// or the key, only the id of the key.
+func Decrypt(key []byte, ciphertext string) (string, error) {
+	if !IsEncrypted(ciphertext) {
+		return "", errors.New("value is not encrypted, expected the prefix " + Prefix)
+	}
+	keyID, data, ok := strings.Cut(strings.TrimPrefix(ciphertext, Prefix), ":")
+	if !ok {
+		return "", errors.New("invalid encrypted value: missing key id")
+	}
+	if keyID != KeyID(key) {
+		return "", fmt.Errorf("value is encrypted with key %s, the given key is %s", keyID, KeyID(key))
+	}
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", errors.New("invalid encrypted value: ciphertext is not base64 encoded")
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("invalid encrypted value: ciphertext is too short")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value with key %s: message authentication failed", keyID)
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key: key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+type keyContextKey struct{}
+
+// WithKey returns a context that supplies key to std.decrypt
+func WithKey(ctx context.Context, key []byte) context.Context {
+	return context.WithValue(ctx, keyContextKey{}, key)
+}
+
+// KeyFromContext returns the key added to ctx with WithKey
+func KeyFromContext(ctx context.Context) ([]byte, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	key, ok := ctx.Value(keyContextKey{}).([]byte)
+	return key, ok && len(key) > 0
+}
@@ -0,0 +1,81 @@
+package encrypt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hexops/autogold/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) []byte {
+	key := make([]byte, KeySize)
+	for i := range key {
+		key[i] = b
+	}
+	return key
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := testKey(1)
+
+	ciphertext, err := Encrypt(key, "hunter2")
+	require.NoError(t, err)
+	require.True(t, IsEncrypted(ciphertext))
+	require.NotContains(t, ciphertext, "hunter2")
+	autogold.Expect("aml:v1:72cd6e84:").Equal(t, ciphertext[:len(Prefix)+9])
+
+	plaintext, err := Decrypt(key, ciphertext)
+	require.NoError(t, err)
+	autogold.Expect("hunter2").Equal(t, plaintext)
+}
+
+func TestDecryptErrors(t *testing.T) {
+	ciphertext, err := Encrypt(testKey(1), "hunter2")
+	require.NoError(t, err)
+
+	_, err = Decrypt(testKey(2), ciphertext)
+	autogold.Expect("value is encrypted with key 72cd6e84, the given key is 75877bb4").Equal(t, err.Error())
+
+	tampered := ciphertext[:len(ciphertext)-4] + "AAA="
+	_, err = Decrypt(testKey(1), tampered)
+	autogold.Expect("failed to decrypt value with key 72cd6e84: message authentication failed").Equal(t, err.Error())
+
+	_, err = Decrypt(testKey(1), "hunter2")
+	autogold.Expect("value is not encrypted, expected the prefix aml:v1:").Equal(t, err.Error())
+}
+
+func TestKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+
+	key, err := WriteKeyFile(path)
+	require.NoError(t, err)
+
+	read, err := ReadKeyFile(path)
+	require.NoError(t, err)
+	require.Equal(t, key, read)
+
+	_, err = WriteKeyFile(path)
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("not a key"), 0600))
+	_, err = ReadKeyFile(path)
+	require.NotContains(t, err.Error(), "not a key")
+}
+
+func TestRewrite(t *testing.T) {
+	data, err := os.ReadFile("testdata/input.acorn")
+	require.NoError(t, err)
+
+	result, n, err := Rewrite("input.acorn", data, func(s string) (string, bool, error) {
+		if IsEncrypted(s) {
+			return "", false, nil
+		}
+		return Prefix + strings.ToUpper(s), true, nil
+	})
+	require.NoError(t, err)
+	autogold.Expect(int(3)).Equal(t, n)
+	autogold.ExpectFile(t, autogold.Raw(result))
+}
@@ -0,0 +1,110 @@
+package encrypt
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/acorn-io/aml/pkg/ast"
+	"github.com/acorn-io/aml/pkg/literal"
+	"github.com/acorn-io/aml/pkg/parser"
+	"github.com/acorn-io/aml/pkg/token"
+)
+
+func decryptArg(node ast.Node) (*ast.BasicLit, bool) {
+	call, ok := node.(*ast.CallExpr)
+	if !ok || len(call.Args) != 1 {
+		return nil, false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return nil, false
+	}
+	pkg, ok := sel.X.(*ast.Ident)
+	if !ok || pkg.Name != "std" {
+		return nil, false
+	}
+	if name, ok := sel.Sel.(*ast.Ident); !ok || name.Name != "decrypt" {
+		return nil, false
+	}
+	arg, ok := call.Args[0].(*ast.EmbedDecl)
+	if !ok {
+		return nil, false
+	}
+	lit, ok := arg.Expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return nil, false
+	}
+	return lit, true
+}
+
+// Rewrite replaces the string literals passed to std.decrypt with the result of f. If f returns
+// false the literal is left unchanged, interpolated strings and references are always skipped.
+// Only the literals are replaced, the formatting and comments of the rest of the file are
+// preserved. The number of replaced literals is returned.
+func Rewrite(filename string, data []byte, f func(s string) (string, bool, error)) ([]byte, int, error) {
+	file, err := parser.ParseFile(filename, bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	type replacement struct {
+		start, end int
+		old, value string
+	}
+
+	var (
+		replacements []replacement
+		errs         []error
+	)
+	ast.Walk(file, func(node ast.Node) bool {
+		lit, ok := decryptArg(node)
+		if !ok || len(errs) > 0 {
+			return true
+		}
+		s, err := literal.Unquote(lit.Value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", lit.ValuePos, err))
+			return true
+		}
+		newValue, ok, err := f(s)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", lit.ValuePos, err))
+			return true
+		} else if !ok {
+			return true
+		}
+		start := lit.ValuePos.Offset()
+		replacements = append(replacements, replacement{
+			start: start,
+			end:   start + len(lit.Value),
+			old:   lit.Value,
+			value: strconv.Quote(newValue),
+		})
+		return true
+	}, nil)
+
+	if len(errs) > 0 {
+		return nil, 0, errs[0]
+	}
+
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].start < replacements[j].start
+	})
+
+	var (
+		buf  bytes.Buffer
+		last int
+	)
+	for _, r := range replacements {
+		if r.start < last || r.end > len(data) || string(data[r.start:r.end]) != r.old {
+			return nil, 0, fmt.Errorf("failed to locate string literal in %s at offset %d", filename, r.start)
+		}
+		buf.Write(data[last:r.start])
+		buf.WriteString(r.value)
+		last = r.end
+	}
+	buf.Write(data[last:])
+	return buf.Bytes(), len(replacements), nil
+}
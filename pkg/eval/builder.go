@@ -46,6 +46,11 @@ func Build(file *ast.File, opts ...BuildOption) (*File, error) {
 	}, nil
 }
 
+// BuildExpr builds an expression that can be evaluated with EvalQuery
+func BuildExpr(expr ast.Expr) (Expression, error) {
+	return exprToExpression(expr)
+}
+
 func fileToObject(file *ast.File) (*Struct, error) {
 	fields, err := declsToFields(file.Decls)
 	if err != nil {
@@ -104,6 +109,7 @@ func declToField(decl ast.Decl) (ref Field, err error) {
 		if err != nil {
 			return nil, err
 		}
+		result.Pos = pos(decl.Pos())
 
 		result.Value, err = exprToExpression(v.Expr)
 		return &result, err
@@ -168,12 +174,16 @@ func ifToExpression(c *ast.If) (Expression, error) {
 		return nil, err
 	}
 
-	var elseExpr Expression
+	var (
+		elseExpr Expression
+		elsePos  Position
+	)
 	if c.Else != nil {
 		elseExpr, err = exprToExpression(c.Else)
 		if err != nil {
 			return nil, err
 		}
+		elsePos = pos(c.Else.Pos())
 	}
 
 	condition, err := exprToExpression(c.Condition.Condition)
@@ -186,6 +196,8 @@ func ifToExpression(c *ast.If) (Expression, error) {
 		Condition: condition,
 		Value:     value,
 		Else:      elseExpr,
+		Pos:       pos(c.Struct.Pos()),
+		ElsePos:   elsePos,
 	}, nil
 }
 
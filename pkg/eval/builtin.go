@@ -57,6 +57,7 @@ func init() {
 	data["len"] = NativeFuncValue(Len)
 	data["keys"] = NativeFuncValue(Keys)
 	data["enum"] = NativeFuncValue(Enum)
+	data["sensitive"] = NativeFuncValue(Sensitive)
 	data["int"] = Int()
 	data["any"] = Any(data)
 	data["std"] = addStd(data)
@@ -87,7 +88,18 @@ func (n nativeCallable) Call(ctx context.Context, args []value.CallArgument) (va
 		}
 		argValues = append(argValues, arg.Value)
 	}
-	return n.f(ctx, argValues)
+
+	result, ok, err := n.f(ctx, argValues)
+	if err != nil || !ok || result == nil || result.Kind() == value.BoolKind {
+		return result, ok, err
+	}
+	// the result of a function that is passed a secret is also a secret
+	for _, arg := range argValues {
+		if value.ContainsSecret(arg) {
+			return value.NewSecret(result), true, nil
+		}
+	}
+	return result, ok, err
 }
 
 type NativeFunc func(context.Context, []value.Value) (value.Value, bool, error)
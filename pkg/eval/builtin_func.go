@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"net"
 	"path"
 	"sort"
@@ -18,6 +19,7 @@ import (
 	"strings"
 	"unicode/utf8"
 
+	"github.com/acorn-io/aml/pkg/encrypt"
 	"github.com/acorn-io/aml/pkg/value"
 	"gopkg.in/yaml.v3"
 )
@@ -63,6 +65,8 @@ var (
 		"mod":           NativeFuncValue(Mod),
 		"error":         NativeFuncValue(Error),
 		"debug":         NativeFuncValue(Debug),
+		"secret":        NativeFuncValue(Secret),
+		"decrypt":       NativeFuncValue(Decrypt),
 	}
 )
 
@@ -87,7 +91,7 @@ func Len(_ context.Context, args []value.Value) (value.Value, bool, error) {
 }
 
 func Error(_ context.Context, args []value.Value) (value.Value, bool, error) {
-	s, err := value.ToString(args[0])
+	s, err := displayString(args[0])
 	if err == nil {
 		var v []any
 		for _, x := range args[1:] {
@@ -98,25 +102,77 @@ func Error(_ context.Context, args []value.Value) (value.Value, bool, error) {
 	return nil, false, errors.New(s)
 }
 
-func Debug(_ context.Context, args []value.Value) (value.Value, bool, error) {
-	if !DebugEnabled {
+func Debug(ctx context.Context, args []value.Value) (value.Value, bool, error) {
+	_, tracing := getTracing(ctx)
+	_, logging := logHandler(ctx)
+	if !DebugEnabled && !tracing && !logging {
 		return nil, false, nil
 	}
-	s, err := value.ToString(args[0])
+	s, err := displayString(args[0])
 	if err == nil {
 		var v []any
 		for _, x := range args[1:] {
 			v = append(v, x)
 		}
+		var msg string
 		if strings.Contains(s, "%") {
-			log.Printf("AML DEBUG: "+s, v...)
+			msg = fmt.Sprintf(s, v...)
 		} else {
-			log.Print(append([]any{"AML DEBUG: " + s}, v...))
+			msg = fmt.Sprint(append([]any{s}, v...)...)
+		}
+		traceDebug(ctx, msg)
+		if !logMessage(ctx, slog.LevelDebug, msg) && DebugEnabled {
+			log.Print("AML DEBUG: " + msg)
 		}
 	}
 	return nil, false, nil
 }
 
+// displayString returns the string to print for a message, a secret message is redacted
+func displayString(v value.Value) (string, error) {
+	if value.IsSecret(v) && !value.RevealSecrets {
+		return value.Redacted, nil
+	}
+	return value.ToString(v)
+}
+
+// Secret marks a value as a secret that is redacted in errors, debug output and rendered output
+func Secret(_ context.Context, args []value.Value) (value.Value, bool, error) {
+	return value.NewSecret(args[0]), true, nil
+}
+
+// Decrypt decrypts a value encrypted with aml encrypt using the key in the context, the result is
+// a secret
+func Decrypt(ctx context.Context, args []value.Value) (value.Value, bool, error) {
+	s, err := value.ToString(args[0])
+	if err != nil {
+		return nil, false, err
+	}
+	key, ok := encrypt.KeyFromContext(ctx)
+	if !ok {
+		return nil, false, fmt.Errorf("decrypt requires a key, none was provided")
+	}
+	plaintext, err := encrypt.Decrypt(key, s)
+	if err != nil {
+		return nil, false, err
+	}
+	return value.NewSecret(value.NewValue(plaintext)), true, nil
+}
+
+// Sensitive returns a copy of a schema that marks the values that match it as secrets
+func Sensitive(_ context.Context, args []value.Value) (value.Value, bool, error) {
+	if len(args) != 1 {
+		return nil, false, fmt.Errorf("sensitive expects one schema argument, got %d", len(args))
+	}
+	typeSchema, ok := args[0].(*value.TypeSchema)
+	if !ok {
+		return nil, false, fmt.Errorf("sensitive expects a schema argument, got kind %s, use std.secret to mark a value", args[0].Kind())
+	}
+	result := *typeSchema
+	result.Sensitive = true
+	return &result, true, nil
+}
+
 func Keys(_ context.Context, args []value.Value) (value.Value, bool, error) {
 	v, err := value.Keys(args[0])
 	return value.NewValue(v), true, err
@@ -735,8 +791,9 @@ func Enum(_ context.Context, args []value.Value) (value.Value, bool, error) {
 		return nil, false, fmt.Errorf("can not create an empty enum")
 	}
 
-	for _, arg := range args {
-		s, err := value.ToString(arg)
+	// the alternates are built from the last value so that they are in the order of the args
+	for i := len(args) - 1; i >= 0; i-- {
+		s, err := value.ToString(args[i])
 		if err != nil {
 			return nil, false, err
 		}
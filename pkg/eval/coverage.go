@@ -0,0 +1,126 @@
+package eval
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/acorn-io/aml/pkg/ast"
+	"github.com/acorn-io/aml/pkg/token"
+)
+
+type CoverageKind string
+
+const (
+	CoverField    = CoverageKind("field")
+	CoverIf       = CoverageKind("if")
+	CoverElse     = CoverageKind("else")
+	CoverFor      = CoverageKind("for")
+	CoverFunction = CoverageKind("function")
+)
+
+// CoverageBlock is a field, branch, loop body or function body of a file and the number of
+// times it was evaluated
+type CoverageBlock struct {
+	Kind CoverageKind
+	// Pos is the start of the block and End the position after the end of the block
+	Pos   Position
+	End   Position
+	Count int
+}
+
+// Coverage records which blocks of a file are evaluated. Only blocks of files added with AddFile
+// are recorded.
+type Coverage struct {
+	lock   sync.Mutex
+	blocks map[Position]*CoverageBlock
+}
+
+func NewCoverage() *Coverage {
+	return &Coverage{
+		blocks: map[Position]*CoverageBlock{},
+	}
+}
+
+type coverageKey struct{}
+
+// WithCoverage returns a context that records the blocks evaluated with it in c
+func WithCoverage(ctx context.Context, c *Coverage) context.Context {
+	return context.WithValue(ctx, coverageKey{}, c)
+}
+
+func recordCoverage(ctx context.Context, pos Position) {
+	if ctx == nil {
+		return
+	}
+	if c, ok := ctx.Value(coverageKey{}).(*Coverage); ok {
+		c.record(pos)
+	}
+}
+
+func (c *Coverage) record(pos Position) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if block, ok := c.blocks[pos]; ok {
+		block.Count++
+	}
+}
+
+// AddFile adds the blocks of a file so that blocks that are never evaluated are reported
+func (c *Coverage) AddFile(file *ast.File) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ast.Walk(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.Field:
+			c.add(CoverField, n.Pos(), n.Pos(), n.End())
+		case *ast.LetClause:
+			c.add(CoverField, n.Pos(), n.Pos(), n.End())
+		case *ast.If:
+			c.add(CoverIf, n.Struct.Pos(), n.Struct.Pos(), n.Struct.End())
+			if n.Else != nil {
+				c.add(CoverElse, n.Else.Pos(), n.Else.Pos(), n.Else.End())
+			}
+		case *ast.For:
+			c.add(CoverFor, n.Clause.Pos(), n.Struct.Pos(), n.Struct.End())
+		case *ast.ListComprehension:
+			c.add(CoverFor, n.Clause.Pos(), n.Value.Pos(), n.Value.End())
+		case *ast.Func:
+			c.add(CoverFunction, n.Pos(), n.Body.Pos(), n.Body.End())
+		}
+		return true
+	}, nil)
+}
+
+// add adds a block that is recorded at the position key and spans from start to end
+func (c *Coverage) add(kind CoverageKind, key, start, end token.Pos) {
+	if _, ok := c.blocks[pos(key)]; ok {
+		return
+	}
+	c.blocks[pos(key)] = &CoverageBlock{
+		Kind: kind,
+		Pos:  pos(start),
+		End:  pos(end),
+	}
+}
+
+// Blocks returns the blocks of the added files sorted by file and position
+func (c *Coverage) Blocks() (result []CoverageBlock) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, block := range c.blocks {
+		result = append(result, *block)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Pos.Filename != result[j].Pos.Filename {
+			return result[i].Pos.Filename < result[j].Pos.Filename
+		}
+		if result[i].Pos.Offset != result[j].Pos.Offset {
+			return result[i].Pos.Offset < result[j].Pos.Offset
+		}
+		return result[i].End.Offset > result[j].End.Offset
+	})
+	return
+}
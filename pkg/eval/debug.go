@@ -0,0 +1,138 @@
+package eval
+
+import (
+	"context"
+	"sort"
+
+	"github.com/acorn-io/aml/pkg/value"
+)
+
+// DebugFrame is a field or function call that is being evaluated
+type DebugFrame struct {
+	// Name is the path of the field or function, function calls end with ()
+	Name string
+	Pos  Position
+	// Scope is the scope the field or the body of the function is evaluated in
+	Scope Scope
+	// Depth is the number of nested scopes and function calls the frame is evaluated in
+	Depth int
+}
+
+// Debugger is notified of each field and function call before and after it is evaluated. Enter
+// is called synchronously so it can pause the evaluation, an error returned from Enter stops the
+// evaluation with that error.
+type Debugger interface {
+	Enter(frame DebugFrame) error
+	Exit(frame DebugFrame)
+}
+
+type debuggerKey struct{}
+
+// WithDebugger returns a context that reports the frames evaluated with it to d
+func WithDebugger(ctx context.Context, d Debugger) context.Context {
+	return context.WithValue(ctx, debuggerKey{}, d)
+}
+
+// debugFrame enters the frame named name if the context of the scope has a debugger and returns
+// the function that exits it
+func debugFrame(scope Scope, name string, pos Position) (func(), error) {
+	ctx := scope.Context()
+	if ctx == nil || pos.Line == 0 {
+		return func() {}, nil
+	}
+	d, ok := ctx.Value(debuggerKey{}).(Debugger)
+	if !ok {
+		return func() {}, nil
+	}
+	depth, _ := ctx.Value(depthKey{}).(int)
+	frame := DebugFrame{
+		Name:  name,
+		Pos:   pos,
+		Scope: scope,
+		Depth: depth,
+	}
+	if err := d.Enter(frame); err != nil {
+		return func() {}, err
+	}
+	return func() {
+		d.Exit(frame)
+	}, nil
+}
+
+// ScopeLevel is the keys defined by one scope in a chain of nested scopes
+type ScopeLevel struct {
+	Path  string
+	Depth int
+	Keys  []string
+	// Scope is the scope of the level, looking up a key in it finds the value of this level
+	Scope Scope
+}
+
+// ScopeLevels returns the keys defined in each scope of the chain starting at the innermost
+// scope. Scopes that define no keys and the builtin scope are skipped.
+func ScopeLevels(scope Scope) (result []ScopeLevel) {
+	var last *Struct
+	for {
+		n, ok := scope.(nested)
+		if !ok {
+			return
+		}
+		if _, ok := n.parent.(EmptyScope); ok {
+			// the builtins
+			return
+		}
+		s, _ := n.lookup.(*Struct)
+		if s != nil && s == last {
+			// a struct is pushed again when its fields are looked up
+			scope = n.parent
+			continue
+		}
+		keys := scopeKeys(n, n.lookup)
+		if s != nil || len(keys) > 0 {
+			last = s
+		}
+		if len(keys) > 0 {
+			result = append(result, ScopeLevel{
+				Path:  n.path,
+				Depth: n.depth,
+				Keys:  keys,
+				Scope: n,
+			})
+		}
+		scope = n.parent
+	}
+}
+
+// scopeKeys returns the keys that lookup can find. Keys that fail to evaluate are skipped.
+func scopeKeys(scope Scope, lookup ScopeLookuper) (result []string) {
+	switch l := lookup.(type) {
+	case ScopeData:
+		for key := range l {
+			result = append(result, key)
+		}
+		sort.Strings(result)
+	case ValueScopeLookup:
+		result, _ = value.Keys(l.Value)
+	case rootLookup:
+		result = []string{"$"}
+	case *Struct:
+		seen := map[string]bool{}
+		for _, field := range l.Fields {
+			var keys []string
+			if kv, ok := field.(*KeyValue); ok {
+				if key, ok, err := kv.Key.ToString(scope); err == nil && ok {
+					keys = append(keys, key)
+				}
+			} else {
+				keys, _ = field.AllKeys(scope)
+			}
+			for _, key := range keys {
+				if !seen[key] {
+					seen[key] = true
+					result = append(result, key)
+				}
+			}
+		}
+	}
+	return
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/acorn-io/aml/pkg/value"
 )
 
+// EvalFile builds and evaluates the file. The warnings of the evaluation are added to the
+// collection of the context, see WithWarnings.
 func EvalFile(ctx context.Context, ast *ast.File, opts ...BuildOption) (value.Value, bool, error) {
 	expr, err := Build(ast, opts...)
 	if err != nil {
@@ -32,3 +34,47 @@ func EvalSchema(ctx context.Context, expr Expression) (value.Value, bool, error)
 	})
 	return expr.ToValue(scope)
 }
+
+// EvalQuery evaluates expr in the scope of file. Only the fields of the file that are referenced
+// by expr are evaluated.
+func EvalQuery(ctx context.Context, file *File, expr Expression) (value.Value, bool, error) {
+	scope, err := file.Scope(Builtin.Push(nil, ScopeOption{
+		Context: ctx,
+	}))
+	if err != nil {
+		return nil, false, err
+	}
+	return expr.ToValue(scope)
+}
+
+// EvalPaths evaluates only the fields of file needed to produce the values at paths. The result
+// is an object that contains just those values, nested under the keys of their path. A path that
+// indexes an array includes the whole array, so that looking up the path in the result finds the
+// element. Paths that do not exist are left out.
+func EvalPaths(ctx context.Context, file *File, paths ...[]string) (value.Value, error) {
+	scope := Builtin.Push(nil, ScopeOption{
+		Context: ctx,
+	})
+
+	values := []value.Value{value.NewObject(nil)}
+	for _, path := range paths {
+		v, depth, ok, err := file.ToValueForPath(scope, path)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		path = path[:depth]
+		for i := len(path) - 1; i >= 0; i-- {
+			v = &value.Object{
+				Entries: []value.Entry{{
+					Key:   path[i],
+					Value: v,
+				}},
+			}
+		}
+		values = append(values, v)
+	}
+
+	return value.Merge(values...)
+}
@@ -2,8 +2,10 @@ package eval
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -123,3 +125,308 @@ func TestFileDescribe(t *testing.T) {
 		})
 	}
 }
+
+func TestEvalPaths(t *testing.T) {
+	ast, err := parser.ParseFile("test.acorn", strings.NewReader(`
+args: tag: "latest"
+repo: "nginx"
+containers: web: {
+	image:  "\(repo):\(args.tag)"
+	broken: 1 + "x"
+	if args.tag == "latest" {
+		pull: "always"
+	}
+}
+containers: web: ports: [80, 443]
+let volumes: {data: ["/a", "/b"]}
+mounts: volumes
+unused: 1 + "x"
+`))
+	require.NoError(t, err)
+
+	file, err := Build(ast)
+	require.NoError(t, err)
+
+	v, err := EvalPaths(context.Background(), file,
+		[]string{"containers", "web", "image"},
+		[]string{"containers", "web", "pull"},
+		[]string{"containers", "web", "ports", "0"},
+		[]string{"containers", "web", "ports", "1"},
+		[]string{"mounts", "data", "1"},
+		[]string{"missing"})
+	require.NoError(t, err)
+
+	nv, _, err := value.NativeValue(v)
+	require.NoError(t, err)
+	autogold.Expect(map[string]interface{}{"containers": map[string]interface{}{"web": map[string]interface{}{
+		"image": "nginx:latest",
+		"ports": []interface{}{value.Number("80"), value.Number("443")},
+		"pull":  "always",
+	}}, "mounts": map[string]interface{}{"data": []interface{}{"/a", "/b"}}}).Equal(t, nv)
+
+	_, err = EvalPaths(context.Background(), file, []string{"containers", "web"})
+	autogold.Expect("can not add number to invalid kind string: test.acorn:6:12").Equal(t, err.Error())
+}
+
+func TestWithMaxDepth(t *testing.T) {
+	ast, err := parser.ParseFile("test.acorn", strings.NewReader(`
+let countdown: function {
+	args: n: number
+	return: [if args.n > 0 { countdown(args.n - 1) }, args.n]
+}
+out: countdown(5)
+`))
+	require.NoError(t, err)
+
+	file, err := Build(ast)
+	require.NoError(t, err)
+
+	_, _, err = EvalExpr(context.Background(), file)
+	require.NoError(t, err)
+
+	_, _, err = EvalExpr(WithMaxDepth(context.Background(), 15), file)
+	autogold.Expect(`key not found "args": exceeded max scope depth 16 > 15: test.acorn:4:37`).Equal(t, err.Error())
+}
+
+func TestCoverage(t *testing.T) {
+	ast, err := parser.ParseFile("test.acorn", strings.NewReader(`args: prod: false
+let scale: function {
+	args: n: number
+	return: args.n * 2
+}
+let unused: function {
+	return: 1
+}
+if args.prod {
+	replicas: 3
+} else {
+	replicas: scale(1)
+}
+ports: [for p in [80, 443] { p }]
+`))
+	require.NoError(t, err)
+
+	file, err := Build(ast)
+	require.NoError(t, err)
+
+	coverage := NewCoverage()
+	coverage.AddFile(ast)
+
+	_, _, err = EvalExpr(WithCoverage(context.Background(), coverage), file)
+	require.NoError(t, err)
+
+	var blocks []string
+	for _, block := range coverage.Blocks() {
+		blocks = append(blocks, fmt.Sprintf("%d:%d-%d:%d %s %d", block.Pos.Line, block.Pos.Column,
+			block.End.Line, block.End.Column, block.Kind, block.Count))
+	}
+	autogold.Expect([]string{
+		"1:1-1:18 field 1", "1:7-1:18 field 2", "2:1-5:2 field 1",
+		"2:21-5:2 function 1",
+		"3:2-3:17 field 1",
+		"3:8-3:17 field 2",
+		"4:2-4:20 field 1",
+		"6:1-8:2 field 0",
+		"6:22-8:2 function 0",
+		"7:2-7:11 field 0",
+		"9:14-11:2 if 0",
+		"10:2-10:13 field 0",
+		"11:3-13:2 else 4",
+		"12:2-12:20 field 4",
+		"14:1-14:34 field 1",
+		"14:28-14:33 for 2",
+	}).Equal(t, blocks)
+}
+
+func TestProfiler(t *testing.T) {
+	ast, err := parser.ParseFile("test.acorn", strings.NewReader(`let double: function {
+	args: n: number
+	return: args.n * 2
+}
+values: [for i in [1, 2] { double(i) }]
+`))
+	require.NoError(t, err)
+
+	file, err := Build(ast)
+	require.NoError(t, err)
+
+	profiler := NewProfiler()
+	_, _, err = EvalExpr(WithProfiler(context.Background(), profiler), file)
+	require.NoError(t, err)
+
+	var stacks []string
+	for _, sample := range profiler.Samples() {
+		var frames []string
+		for _, frame := range sample.Stack {
+			frames = append(frames, fmt.Sprintf("%s@%d:%d", frame.Name, frame.Pos.Line, frame.Pos.Column))
+		}
+		stacks = append(stacks, fmt.Sprintf("%s x%d", strings.Join(frames, ";"), sample.Count))
+	}
+	autogold.Expect([]string{
+		"values@5:1 x1", "values@5:1;values[for]@5:14 x2",
+		"values@5:1;values[for]@5:14;double()@1:13 x2",
+		"values@5:1;values[for]@5:14;double()@1:13;double.().return@3:2 x2",
+		"values@5:1;values[for]@5:14;double()@1:13;double.args.n@2:8 x2",
+		"values@5:1;values[for]@5:14;double@1:1 x1",
+		"values@5:1;values[for]@5:14;double@1:1;double.args.n@2:8 x1",
+		"values@5:1;values[for]@5:14;double@1:1;double.args@2:2 x1",
+	}).Equal(t, stacks)
+}
+
+type recordingTracer struct {
+	events []string
+}
+
+func traceString(v value.Value) string {
+	if v != nil && v.Kind() == value.FuncKind {
+		return "(func)"
+	}
+	return fmt.Sprint(v)
+}
+
+func (r *recordingTracer) CallEnter(_ context.Context, call TraceCall) {
+	var args []string
+	for _, arg := range call.Args {
+		args = append(args, traceString(arg.Value))
+	}
+	r.events = append(r.events, fmt.Sprintf("enter %s(%s) %s", call.Name, strings.Join(args, ", "), call.Pos))
+}
+
+func (r *recordingTracer) CallExit(_ context.Context, call TraceCall, result value.Value, err error) {
+	r.events = append(r.events, fmt.Sprintf("exit %s = %s, %v", call.Name, traceString(result), err))
+}
+
+func (r *recordingTracer) Field(_ context.Context, path string, pos Position, result value.Value, err error) {
+	r.events = append(r.events, fmt.Sprintf("field %s %s = %s, %v", path, pos, traceString(result), err))
+}
+
+func (r *recordingTracer) SchemaMerge(_ context.Context, pos Position, _, v, _ value.Value, err error) {
+	r.events = append(r.events, fmt.Sprintf("schema %s %s, %v", pos, traceString(v), err))
+}
+
+func (r *recordingTracer) Debug(_ context.Context, message string) {
+	r.events = append(r.events, "debug "+message)
+}
+
+func (r *recordingTracer) Error(_ context.Context, err error) {
+	r.events = append(r.events, "error "+err.Error())
+}
+
+func TestTracer(t *testing.T) {
+	ast, err := parser.ParseFile("test.acorn", strings.NewReader(`let double: function {
+	args: n: number
+	return: args.n * 2
+}
+a: double(2)
+b: std.debug("a is %v", a)
+c: double("x")
+`))
+	require.NoError(t, err)
+
+	file, err := Build(ast)
+	require.NoError(t, err)
+
+	tracer := &recordingTracer{}
+	_, _, err = file.ToValue(Builtin.Push(nil, ScopeOption{
+		Tracer: tracer,
+	}))
+	require.Error(t, err)
+
+	autogold.Expect([]string{
+		"schema 0:0 {}, <nil>", "field double.args test.acorn:2:2 = (object schema), <nil>",
+		"field double.args.n test.acorn:2:8 = (number schema), <nil>",
+		"field double test.acorn:1:1 = (func), <nil>",
+		"enter double(2) test.acorn:5:10",
+		"field double.args.n test.acorn:2:8 = (number schema), <nil>",
+		`schema test.acorn:1:13 {"n":2}, <nil>`,
+		"field double.().return test.acorn:3:2 = 4, <nil>",
+		"exit double = 4, <nil>",
+		"field a test.acorn:5:1 = 4, <nil>",
+		"field double.args test.acorn:2:2 = (object schema), <nil>",
+		"field double.args.n test.acorn:2:8 = (number schema), <nil>",
+		"field double test.acorn:1:1 = (func), <nil>",
+		"enter double(2) test.acorn:5:10",
+		"field double.args.n test.acorn:2:8 = (number schema), <nil>",
+		`schema test.acorn:1:13 {"n":2}, <nil>`,
+		"field double.().return test.acorn:3:2 = 4, <nil>",
+		"exit double = 4, <nil>",
+		"field a test.acorn:5:1 = 4, <nil>",
+		"enter std.debug(a is %v, 4) test.acorn:6:13",
+		"debug a is 4",
+		"exit std.debug = <nil>, <nil>",
+		"field b test.acorn:6:1 = <nil>, <nil>",
+		"enter double(x) test.acorn:7:10",
+		"field double.args.n test.acorn:2:8 = (number schema), <nil>",
+		"error schema violation double.args.n: expected kind number but got string with value (x) (test.acorn:2:8)",
+		`schema test.acorn:1:13 {"n":"x"}, schema violation double.args.n: expected kind number but got string with value (x) (test.acorn:2:8)`,
+		"exit double = <nil>, invalid arguments: schema violation double.args.n: expected kind number but got string with value (x) (test.acorn:2:8): test.acorn:1:13",
+		"field c test.acorn:7:1 = <nil>, invalid arguments: schema violation double.args.n: expected kind number but got string with value (x) (test.acorn:2:8): test.acorn:1:13 (backtrace 7:10)",
+	}).Equal(t, tracer.events)
+}
+
+func TestLogHandler(t *testing.T) {
+	ast, err := parser.ParseFile("test.acorn", strings.NewReader(`a: 1
+b: c: std.debug("a is %v", a)
+`))
+	require.NoError(t, err)
+
+	file, err := Build(ast)
+	require.NoError(t, err)
+
+	buf := &strings.Builder{}
+	h := slog.NewTextHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	_, _, err = EvalExpr(WithLogHandler(context.Background(), h), file)
+	require.NoError(t, err)
+	autogold.Expect(`level=DEBUG msg="a is 1" pos=test.acorn:2:16 path=b.c
+`).Equal(t, buf.String())
+}
+
+func TestWarnings(t *testing.T) {
+	ast, err := parser.ParseFile("test.acorn", strings.NewReader(`args: {
+	// Deprecated: use replicas
+	scale: 1
+	replicas: 1
+	labels: {app?: string}
+	ports: [number]
+	env: {LOG: "info"}
+	hosts: ["localhost"]
+	image: string || default "nginx"
+}
+profiles: prod: replicas: 3
+out: args
+`))
+	require.NoError(t, err)
+
+	file, err := Build(ast, BuildOption{
+		Args:     map[string]any{"scale": 2, "unknown": true},
+		Profiles: []string{"prod", "missing?"},
+	})
+	require.NoError(t, err)
+
+	warnings := NewWarnings()
+	_, _, err = EvalExpr(WithWarnings(context.Background(), warnings), file)
+	require.NoError(t, err)
+
+	var result []string
+	for _, w := range warnings.List() {
+		result = append(result, w.Path+": "+w.String())
+	}
+	autogold.Expect([]string{
+		"profiles: profile missing is not defined: test.acorn:1:1",
+		"args.scale: arg scale is deprecated: use replicas: test.acorn:3:2",
+		"args.unknown: arg unknown is not defined: test.acorn:1:1",
+		"args.labels: arg labels is not set, using the implicit default {}: test.acorn:5:2",
+		"args.ports: arg ports is not set, using the implicit default []: test.acorn:6:2",
+	}).Equal(t, result)
+
+	_, _, err = EvalExpr(WithWarnings(context.Background(), &Warnings{AsErrors: true}), file)
+	autogold.Expect("profile missing is not defined: test.acorn:1:1").Equal(t, err.Error())
+}
@@ -239,7 +239,10 @@ func (c *Call) ToValue(scope Scope) (value.Value, bool, error) {
 		args = append(args, arg)
 	}
 
-	v, ok, err = value.Call(scope.Context(), v, args...)
+	ctx := withCallSite(scope.Context(), c.Pos, scope.Path())
+	exit := traceCall(ctx, callName(c.Func), c.Pos, args)
+	v, ok, err = value.Call(ctx, v, args...)
+	exit(v, err)
 	if err != nil {
 		return v, ok, errors.NewErrEval(value.Position(c.Pos), err)
 	}
@@ -251,6 +254,9 @@ type If struct {
 	Condition Expression
 	Value     Expression
 	Else      Expression
+	// Pos is the position of the body and ElsePos the position of the else branch
+	Pos     Position
+	ElsePos Position
 }
 
 func (i *If) ToValue(scope Scope) (value.Value, bool, error) {
@@ -269,11 +275,13 @@ func (i *If) ToValue(scope Scope) (value.Value, bool, error) {
 	}
 	if !b {
 		if i.Else != nil {
+			recordCoverage(scope.Context(), i.ElsePos)
 			return i.Else.ToValue(scope)
 		}
 		return nil, false, nil
 	}
 
+	recordCoverage(scope.Context(), i.Pos)
 	return i.Value.ToValue(scope)
 }
 
@@ -282,7 +290,10 @@ type Interpolation struct {
 }
 
 func (i *Interpolation) ToValue(scope Scope) (value.Value, bool, error) {
-	var result []string
+	var (
+		result []string
+		secret bool
+	)
 	for _, part := range i.Parts {
 		switch v := part.(type) {
 		case string:
@@ -312,10 +323,14 @@ func (i *Interpolation) ToValue(scope Scope) (value.Value, bool, error) {
 			if err != nil || !ok {
 				return nil, ok, err
 			}
+			secret = secret || value.ContainsSecret(val)
 			result = append(result, value.Escape(fmt.Sprint(nv)))
 		}
 	}
 	s, err := value.Unquote(strings.Join(result, ""))
+	if secret {
+		return value.NewSecret(value.NewValue(s)), true, err
+	}
 	return value.NewValue(s), true, err
 }
 
@@ -408,7 +423,10 @@ func (f *For) ToValue(scope Scope) (value.Value, bool, error) {
 			data[f.Value] = item.Value
 		}
 
+		recordCoverage(scope.Context(), f.Position)
+		endFrame := profileFrame(scope.Context(), scope.Path(), "[for]", f.Position)
 		newValue, ok, err := f.Body.ToValue(scope.Push(ScopeData(data)))
+		endFrame()
 		if err != nil {
 			return nil, false, err
 		}
@@ -127,10 +127,20 @@ func (k *KeyValue) AllKeys(scope Scope) ([]string, error) {
 	return []string{s}, nil
 }
 
-func (k *KeyValue) getValueValue(scope Scope, key string) (ret value.Value, _ bool, _ error) {
+func (k *KeyValue) getValueValue(scope Scope, key string) (ret value.Value, _ bool, retErr error) {
 	scope = scope.Push(nil, ScopeOption{
 		Path: key,
 	})
+	recordCoverage(scope.Context(), k.Pos)
+	defer profileFrame(scope.Context(), scope.Path(), "", k.Pos)()
+	defer func() {
+		traceField(scope.Context(), scope.Path(), k.Pos, ret, retErr)
+	}()
+	exit, err := debugFrame(scope, frameName(scope.Path(), ""), k.Pos)
+	if err != nil {
+		return nil, false, err
+	}
+	defer exit()
 	v, ok, err := k.Value.ToValue(scope)
 	if err != nil || !ok {
 		return nil, ok, err
@@ -200,6 +210,22 @@ type FieldKey struct {
 	disallowedKeys []string
 }
 
+// staticKey returns the key if it is a string that is not computed, otherwise ""
+func (k *FieldKey) staticKey() string {
+	if v, ok := k.Key.(Value); ok {
+		if s, ok := v.Value.(value.String); ok {
+			return string(s)
+		}
+	}
+	return ""
+}
+
+// errSecret is returned for a key that is a secret, keys are not redacted in the output so the
+// secret would be revealed
+func (k *FieldKey) errSecret() error {
+	return errors.NewErrEval(value.Position(k.Pos), fmt.Errorf("a secret can not be used as an object key"))
+}
+
 func (k *FieldKey) IsMatch() bool {
 	return k.Match != nil
 }
@@ -224,6 +250,8 @@ func (k *FieldKey) ToString(scope Scope) (string, bool, error) {
 	v, ok, err := k.Key.ToValue(scope)
 	if err != nil || !ok {
 		return "", ok, err
+	} else if value.IsSecret(v) {
+		return "", false, k.errSecret()
 	}
 
 	s, err := value.ToString(v)
@@ -256,6 +284,8 @@ func (k *FieldKey) Equals(scope Scope, key string) (_ bool, returnErr error) {
 			Key:       key,
 			Undefined: v,
 		})
+	} else if value.IsSecret(v) {
+		return false, k.errSecret()
 	}
 
 	keyPattern, err := value.ToString(v)
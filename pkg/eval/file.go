@@ -1,6 +1,7 @@
 package eval
 
 import (
+	"fmt"
 	"sort"
 
 	"github.com/acorn-io/aml/pkg/schema"
@@ -30,9 +31,15 @@ func (f *File) DescribeFile() (*schema.File, error) {
 		return nil, err
 	}
 
+	profilesSchema, err := value.DescribeObject(value.SchemaContext{}, fun.(*Function).ProfilesSchema)
+	if err != nil {
+		return nil, err
+	}
+
 	return &schema.File{
 		Args:         *argsSchema,
 		ProfileNames: profiles.Describe(),
+		Profiles:     *profilesSchema,
 	}, nil
 }
 
@@ -55,6 +62,47 @@ func (f *File) ToValue(scope Scope) (value.Value, bool, error) {
 	return value.Call(scope.Context(), call, f.CallArgs()...)
 }
 
+// Scope returns a scope in which the fields of the file body can be looked up. Fields are only
+// evaluated when they are looked up, so errors in fields that are not referenced are not
+// returned.
+func (f *File) Scope(scope Scope) (Scope, error) {
+	body, scope, err := f.bodyScope(scope)
+	if err != nil || body == nil {
+		return scope, err
+	}
+	return scope.Push(body), nil
+}
+
+// ToValueForPath returns the value at path in the file body, only evaluating the fields needed
+// to produce it. See Struct.ToValueForPath for how arrays along the path are returned.
+func (f *File) ToValueForPath(scope Scope, path []string) (value.Value, int, bool, error) {
+	body, scope, err := f.bodyScope(scope)
+	if err != nil || body == nil {
+		return nil, 0, false, err
+	}
+	return body.ToValueForPath(scope, path)
+}
+
+// bodyScope returns the body of the file and the scope it is evaluated in
+func (f *File) bodyScope(scope Scope) (*Struct, Scope, error) {
+	call, ok, err := f.ToFunction(scope)
+	if err != nil || !ok {
+		return nil, scope, err
+	}
+
+	fun := call.(*Function)
+	callScope, err := fun.callScope(scope.Context(), f.CallArgs())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, ok := fun.Body.(*Struct)
+	if !ok {
+		return nil, nil, fmt.Errorf("file body %T is not a struct", fun.Body)
+	}
+	return body, callScope, nil
+}
+
 func (f *File) CallArgs() (result []value.CallArgument) {
 	var keys []string
 	for k := range f.Args {
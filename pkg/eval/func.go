@@ -2,6 +2,7 @@ package eval
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -34,15 +35,17 @@ func (f *FunctionDefinition) ToValue(scope Scope) (value.Value, bool, error) {
 		Fields:   bodyFields,
 	}
 	return &Function{
-		Pos:            f.Pos,
-		Scope:          scope,
-		Body:           body,
-		ArgsSchema:     argsSchema,
-		ArgNames:       argNames,
-		ProfileNames:   profileNames,
-		ProfilesSchema: profileSchema,
-		ReturnBody:     f.ReturnBody,
-		AssignRoot:     f.AssignRoot,
+		Pos:              f.Pos,
+		Scope:            scope,
+		Body:             body,
+		ArgsSchema:       argsSchema,
+		ArgNames:         argNames,
+		ProfileNames:     profileNames,
+		ProfilesSchema:   profileSchema,
+		ReturnBody:       f.ReturnBody,
+		AssignRoot:       f.AssignRoot,
+		AllowUnknownArgs: f.AllowUnknownArgs,
+		BodyPos:          f.Body.Position,
 	}, true, nil
 }
 
@@ -73,15 +76,23 @@ func (f *FunctionDefinition) toSchema(scope Scope, argDefs []Field, fieldName st
 		obj = &schema.Object{}
 	}
 
-	var names Names
+	var (
+		names Names
+		defs  = argKeyValues(argDefs, fieldName)
+	)
 	keys, err := value.Keys(args)
 	for _, key := range keys {
 		name := Name{
 			Name: key,
 		}
+		if kv, ok := defs[key]; ok {
+			name.Pos = kv.Pos
+			name.Default = isLiteral(kv.Value)
+		}
 		for _, field := range obj.Fields {
 			if field.Name == key {
 				name.Description = field.Description
+				name.Default = name.Default || hasDefault(&field.Type)
 				break
 			}
 		}
@@ -91,6 +102,57 @@ func (f *FunctionDefinition) toSchema(scope Scope, argDefs []Field, fieldName st
 	return names, args, err
 }
 
+// argKeyValues returns the key values defined in the structs of the fields named fieldName by
+// their key
+func argKeyValues(fields []Field, fieldName string) map[string]*KeyValue {
+	result := map[string]*KeyValue{}
+	for _, field := range fields {
+		kv, ok := field.(*KeyValue)
+		if !ok || kv.Key.staticKey() != fieldName {
+			continue
+		}
+		s, ok := kv.Value.(*Struct)
+		if !ok {
+			continue
+		}
+		for _, field := range s.Fields {
+			if kv, ok := field.(*KeyValue); ok {
+				if key := kv.Key.staticKey(); key != "" {
+					result[key] = kv
+				}
+			}
+		}
+	}
+	return result
+}
+
+// isLiteral returns true if the expression is a literal or a struct or list of only literals.
+// The value of an arg written this way is its default even though it is not marked as one.
+func isLiteral(expr Expression) bool {
+	switch e := expr.(type) {
+	case Value:
+		return true
+	case *Parens:
+		return isLiteral(e.Expr)
+	case *Struct:
+		for _, field := range e.Fields {
+			kv, ok := field.(*KeyValue)
+			if !ok || kv.Local || kv.Optional || kv.Key.staticKey() == "" || !isLiteral(kv.Value) {
+				return false
+			}
+		}
+		return true
+	case *Array:
+		for _, item := range e.Items {
+			if !isLiteral(item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
 func (f *FunctionDefinition) splitFields() (argFields []Field, bodyFields []Field) {
 	for _, field := range f.Body.Fields {
 		arg, ok := field.(IsArgumentDefinition)
@@ -117,6 +179,11 @@ type Function struct {
 	ProfileNames   Names
 	ReturnBody     bool
 	AssignRoot     bool
+	// AllowUnknownArgs is set for the function of a file, which accepts args it does not define
+	AllowUnknownArgs bool
+	// BodyPos is the position of the body in the source, warnings about a function without a
+	// position, such as the function of a file, refer to it
+	BodyPos Position
 }
 
 type Names []Name
@@ -124,20 +191,36 @@ type Names []Name
 type Name struct {
 	Name        string
 	Description string
+	Pos         Position
+	// Default is set if a default for the arg is written in the source, as opposed to the
+	// empty object or array an object or array arg defaults to
+	Default bool
 }
 
 func (n Names) Describe() (result schema.Names) {
 	for _, name := range n {
-		result = append(result, schema.Name(name))
+		result = append(result, schema.Name{
+			Name:        name.Name,
+			Description: name.Description,
+		})
 	}
 	return
 }
 
+func (n Names) get(name string) (Name, bool) {
+	for _, x := range n {
+		if x.Name == name {
+			return x, true
+		}
+	}
+	return Name{}, false
+}
+
 func (c *Function) Kind() value.Kind {
 	return value.FuncKind
 }
 
-func (c *Function) getProfiles(v value.Value) (profiles []value.Value, profileStringNames []string, _ bool, _ error) {
+func (c *Function) getProfiles(ctx context.Context, v value.Value) (profiles []value.Value, profileStringNames []string, _ bool, _ error) {
 	v, ok, err := value.Lookup(v, value.NewValue("profiles"))
 	if err != nil || !ok {
 		return nil, nil, ok, err
@@ -168,6 +251,9 @@ func (c *Function) getProfiles(v value.Value) (profiles []value.Value, profileSt
 			return nil, nil, false, err
 		} else if !ok {
 			if optional {
+				if err := warn(ctx, c.warnPos(), "profiles", "profile %s is not defined", profileNameString); err != nil {
+					return nil, nil, false, err
+				}
 				continue
 			}
 			return nil, nil, false, fmt.Errorf("failed to find profile %s", profileName)
@@ -180,7 +266,7 @@ func (c *Function) getProfiles(v value.Value) (profiles []value.Value, profileSt
 	return profiles, profileStringNames, true, nil
 }
 
-func (c *Function) callArgumentToValue(args []value.CallArgument) (value.Value, error) {
+func (c *Function) callArgumentToValue(ctx context.Context, args []value.CallArgument) (value.Value, error) {
 	var (
 		argValues      []value.Value
 		profiles       []value.Value
@@ -197,7 +283,7 @@ func (c *Function) callArgumentToValue(args []value.CallArgument) (value.Value,
 			}))
 		} else if arg.Value.Kind() != value.ObjectKind {
 			return nil, fmt.Errorf("invalid argument kind %s (index %d)", arg.Value.Kind(), i)
-		} else if profile, profileNames, profilesSet, err := c.getProfiles(arg.Value); err != nil {
+		} else if profile, profileNames, profilesSet, err := c.getProfiles(ctx, arg.Value); err != nil {
 			return nil, err
 		} else if profilesSet {
 			profilesActive = append(profilesActive, profileNames...)
@@ -223,18 +309,118 @@ func (c *Function) callArgumentToValue(args []value.CallArgument) (value.Value,
 		}
 	}
 
-	validated, err := value.Merge(c.ArgsSchema, argValue)
+	validated, err := MergeSchema(ctx, c.Pos, c.ArgsSchema, argValue)
 	if err != nil {
 		return validated, errors.NewErrEval(value.Position(c.Pos), &ErrInvalidArgument{
 			Err: err,
 		})
 	}
 
+	if err := c.warnArgs(ctx, argValue, validated); err != nil {
+		return nil, err
+	}
+
 	return value.Merge(validated, value.NewObject(map[string]any{
 		"profiles": profilesActive,
 	}))
 }
 
+// warnPos returns the position warnings about the function refer to
+func (c *Function) warnPos() Position {
+	if c.Pos.Line == 0 {
+		return c.BodyPos
+	}
+	return c.Pos
+}
+
+// warnArgs warns about the args that are deprecated. For the function of a file it also warns
+// about the args that the file does not define and the args that are not set and take a default
+// that was not written in the file, such as the empty object of an object arg.
+func (c *Function) warnArgs(ctx context.Context, args, validated value.Value) error {
+	if _, ok := ctx.Value(warningsKey{}).(*Warnings); !ok {
+		return nil
+	}
+
+	keys, err := value.Keys(args)
+	if err != nil {
+		return err
+	}
+
+	set := map[string]bool{}
+	for _, key := range keys {
+		set[key] = true
+		name, ok := c.ArgNames.get(key)
+		if !ok {
+			if c.AllowUnknownArgs {
+				if err := warn(ctx, c.warnPos(), "args."+key, "arg %s is not defined", key); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if reason, ok := deprecation(name.Description); ok {
+			if err := warn(ctx, name.Pos, "args."+key, "arg %s is deprecated%s", key, reason); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !c.AllowUnknownArgs {
+		return nil
+	}
+
+	for _, name := range c.ArgNames {
+		if set[name.Name] {
+			continue
+		}
+		if name.Default {
+			continue
+		}
+		def, ok, err := value.Lookup(validated, value.NewValue(name.Name))
+		if err != nil || !ok {
+			continue
+		}
+		if !value.RevealSecrets {
+			def = value.Redact(def)
+		}
+		nv, ok, err := value.OrderedNativeValue(def)
+		if err != nil || !ok {
+			continue
+		}
+		text, err := json.Marshal(nv)
+		if err != nil {
+			continue
+		}
+		if err := warn(ctx, name.Pos, "args."+name.Name, "arg %s is not set, using the implicit default %s", name.Name, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deprecation returns the reason an arg is deprecated if its description starts with Deprecated
+func deprecation(description string) (string, bool) {
+	reason, ok := strings.CutPrefix(description, "Deprecated")
+	if !ok {
+		return "", false
+	}
+	reason = strings.TrimSpace(strings.TrimPrefix(reason, ":"))
+	if reason == "" {
+		return "", true
+	}
+	return ": " + reason, true
+}
+
+// hasDefault returns true if the type or one of its alternates has a default
+func hasDefault(t *schema.FieldType) bool {
+	for ; t != nil; t = t.Alternate {
+		if t.Default != nil {
+			return true
+		}
+	}
+	return false
+}
+
 type ErrInvalidArgument struct {
 	Err error
 }
@@ -243,6 +429,10 @@ func (e *ErrInvalidArgument) Error() string {
 	return fmt.Sprintf("invalid arguments: %v", e.Err)
 }
 
+func (e *ErrInvalidArgument) Unwrap() error {
+	return e.Err
+}
+
 type rootLookup struct {
 	f *Function
 }
@@ -256,23 +446,63 @@ func (r rootLookup) ScopeLookup(scope Scope, key string) (value.Value, bool, err
 
 type depthKey struct{}
 
+type maxDepthKey struct{}
+
 const MaxCallDepth = 100
 
+// WithMaxDepth returns a context that limits the depth of nested scopes and function calls
+// evaluated with it. The limit can not be raised above MaxCallDepth.
+func WithMaxDepth(ctx context.Context, depth int) context.Context {
+	return context.WithValue(ctx, maxDepthKey{}, depth)
+}
+
+func maxDepth(ctx context.Context) int {
+	if depth, ok := ctx.Value(maxDepthKey{}).(int); ok && depth < MaxCallDepth {
+		return depth
+	}
+	return MaxCallDepth
+}
+
 func (c *Function) Call(ctx context.Context, args []value.CallArgument) (value.Value, bool, error) {
-	argsValue, err := c.callArgumentToValue(args)
+	defer profileFrame(ctx, c.Scope.Path(), "()", c.Pos)()
+	scope, err := c.callScope(ctx, args)
 	if err != nil {
 		return nil, false, err
 	}
 
+	exit, err := debugFrame(scope, frameName(c.Scope.Path(), "()"), c.Pos)
+	if err != nil {
+		return nil, false, err
+	}
+	defer exit()
+
+	recordCoverage(ctx, c.Pos)
+	ret, ok, err := c.Body.ToValue(scope)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	if c.ReturnBody {
+		return ret, true, nil
+	}
+	return value.Lookup(ret, value.NewValue("return"))
+}
+
+// callScope returns the scope the body of the function is evaluated in for the given arguments
+func (c *Function) callScope(ctx context.Context, args []value.CallArgument) (Scope, error) {
+	argsValue, err := c.callArgumentToValue(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+
 	depth, _ := ctx.Value(depthKey{}).(int)
-	if depth > MaxCallDepth {
-		return nil, false, fmt.Errorf("exceeded max call depth %d > %d", depth, MaxCallDepth)
+	if max := maxDepth(ctx); depth > max {
+		return nil, fmt.Errorf("exceeded max call depth %d > %d", depth, max)
 	}
 	ctx = context.WithValue(ctx, depthKey{}, depth+1)
 
 	select {
 	case <-ctx.Done():
-		return nil, false, fmt.Errorf("context is closed: %w", ctx.Err())
+		return nil, fmt.Errorf("context is closed: %w", ctx.Err())
 	default:
 	}
 
@@ -292,13 +522,5 @@ func (c *Function) Call(ctx context.Context, args []value.CallArgument) (value.V
 			f: c,
 		})
 	}
-
-	ret, ok, err := c.Body.ToValue(scope)
-	if err != nil || !ok {
-		return nil, ok, err
-	}
-	if c.ReturnBody {
-		return ret, true, nil
-	}
-	return value.Lookup(ret, value.NewValue("return"))
+	return scope, nil
 }
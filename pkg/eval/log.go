@@ -0,0 +1,67 @@
+package eval
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type logHandlerKey struct{}
+
+type callSiteKey struct{}
+
+type callSite struct {
+	Pos  Position
+	Path string
+}
+
+// WithLogHandler returns a context that sends the messages of debug() and the warnings of the
+// evaluations done with it to h instead of the log package. Each record has the position of the
+// call and the path of the field it was made in as the pos and path attributes.
+func WithLogHandler(ctx context.Context, h slog.Handler) context.Context {
+	return context.WithValue(ctx, logHandlerKey{}, h)
+}
+
+func logHandler(ctx context.Context) (slog.Handler, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	h, ok := ctx.Value(logHandlerKey{}).(slog.Handler)
+	return h, ok
+}
+
+// withCallSite returns a context that records the position and path of a function call so that
+// messages logged by the function can refer to them
+func withCallSite(ctx context.Context, pos Position, path string) context.Context {
+	if _, ok := logHandler(ctx); !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, callSiteKey{}, callSite{
+		Pos:  pos,
+		Path: path,
+	})
+}
+
+// logMessage sends msg to the log handler of the context with the position and path of the
+// current call site. False is returned if the context has no log handler.
+func logMessage(ctx context.Context, level slog.Level, msg string) bool {
+	site, _ := ctx.Value(callSiteKey{}).(callSite)
+	return logAt(ctx, level, site.Pos, site.Path, msg)
+}
+
+// logAt sends msg to the log handler of the context with the given position and path. False is
+// returned if the context has no log handler.
+func logAt(ctx context.Context, level slog.Level, pos Position, path, msg string) bool {
+	h, ok := logHandler(ctx)
+	if !ok {
+		return false
+	}
+	if !h.Enabled(ctx, level) {
+		return true
+	}
+
+	record := slog.NewRecord(time.Now(), level, msg, 0)
+	record.AddAttrs(slog.String("pos", pos.String()), slog.String("path", path))
+	_ = h.Handle(ctx, record)
+	return true
+}
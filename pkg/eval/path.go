@@ -0,0 +1,128 @@
+package eval
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/acorn-io/aml/pkg/value"
+)
+
+// ToValueForPath returns the value at path in the struct. Only the fields needed to produce the
+// value are evaluated. While the fields along the path are struct literals the lookup descends
+// into them with ToValueForKey semantics, otherwise the value of the matching fields is evaluated
+// and the rest of the path is looked up in the result. The lookup stops at an array, the array is
+// returned whole with the number of elements of path that lead to it.
+func (s *Struct) ToValueForPath(scope Scope, path []string) (value.Value, int, bool, error) {
+	if len(path) == 0 {
+		v, ok, err := s.ToValue(scope)
+		return v, 0, ok, err
+	}
+
+	var (
+		key    = path[0]
+		values []value.Value
+		depths []int
+	)
+	scope = scope.Push(s)
+
+	for _, field := range s.Fields {
+		if kv, ok := field.(*KeyValue); ok {
+			if kv.Local {
+				continue
+			}
+			if body, ok := kv.Value.(*Struct); ok && !kv.Key.IsMatch() && len(path) > 1 {
+				if ok, err := kv.Key.Equals(scope, key); err != nil {
+					return nil, 0, false, err
+				} else if !ok {
+					continue
+				}
+				val, depth, ok, err := body.ToValueForPath(scope.Push(nil, ScopeOption{
+					Path: key,
+				}), path[1:])
+				if err != nil {
+					return nil, 0, false, err
+				} else if ok {
+					values = append(values, val)
+					depths = append(depths, depth+1)
+				}
+				continue
+			}
+		}
+
+		val, ok, err := field.ToValueForKey(scope, key)
+		if c := (*ErrKeyUndefined)(nil); errors.As(err, &c) {
+			continue
+		} else if err != nil {
+			return nil, 0, false, err
+		} else if !ok {
+			continue
+		}
+
+		val, depth, ok, err := lookupUntilArray(val, path[1:])
+		if err != nil {
+			return nil, 0, false, err
+		} else if ok {
+			values = append(values, val)
+			depths = append(depths, depth+1)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, 0, false, nil
+	}
+	for _, depth := range depths[1:] {
+		if depth != depths[0] {
+			// one of the fields is an array where another is an object
+			return nil, 0, false, fmt.Errorf("can not merge an array with an object at %s",
+				strings.Join(path[:min(depth, depths[0])], "."))
+		}
+	}
+
+	result, err := value.Merge(values...)
+	return result, depths[0], result != nil, err
+}
+
+// lookupUntilArray looks up path in v like LookupPath but stops at the first array along the
+// path. The number of elements of path looked up is returned with the value.
+func lookupUntilArray(v value.Value, path []string) (value.Value, int, bool, error) {
+	for i, key := range path {
+		if v.Kind() == value.ArrayKind {
+			return v, i, true, nil
+		}
+		var (
+			ok  bool
+			err error
+		)
+		v, ok, err = value.Lookup(v, value.NewValue(key))
+		if err != nil || !ok {
+			return nil, 0, ok, err
+		}
+	}
+	return v, len(path), true, nil
+}
+
+// LookupPath returns the value at path in v. Path elements are object keys or, for arrays,
+// indexes.
+func LookupPath(v value.Value, path []string) (value.Value, bool, error) {
+	for _, key := range path {
+		var (
+			ok  bool
+			err error
+		)
+		if v.Kind() == value.ArrayKind {
+			i, convErr := strconv.Atoi(key)
+			if convErr != nil {
+				return nil, false, fmt.Errorf("invalid array index %q: %w", key, convErr)
+			}
+			v, ok, err = value.Index(v, value.NewValue(i))
+		} else {
+			v, ok, err = value.Lookup(v, value.NewValue(key))
+		}
+		if err != nil || !ok {
+			return nil, ok, err
+		}
+	}
+	return v, true, nil
+}
@@ -0,0 +1,170 @@
+package eval
+
+import (
+	"context"
+	"runtime/metrics"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const allocsMetric = "/gc/heap/allocs:objects"
+
+// ProfileFrame is a function call, loop iteration or field evaluation
+type ProfileFrame struct {
+	Name string
+	Pos  Position
+}
+
+// ProfileSample is the time spent and the objects allocated in the last frame of a stack,
+// excluding the frames called from it
+type ProfileSample struct {
+	// Stack is ordered from the outermost frame to the frame the sample is for
+	Stack  []ProfileFrame
+	Wall   time.Duration
+	Allocs int64
+	Count  int
+}
+
+// Profiler attributes the wall time and allocations of an evaluation to the frames of AML source
+// that were evaluated. A profiler records a single evaluation at a time.
+type Profiler struct {
+	lock    sync.Mutex
+	start   time.Time
+	stack   []*profileEntry
+	samples map[string]*ProfileSample
+	metric  []metrics.Sample
+}
+
+type profileEntry struct {
+	frame       ProfileFrame
+	start       time.Time
+	allocs      uint64
+	childWall   time.Duration
+	childAllocs uint64
+}
+
+func NewProfiler() *Profiler {
+	return &Profiler{
+		start:   time.Now(),
+		samples: map[string]*ProfileSample{},
+		metric:  []metrics.Sample{{Name: allocsMetric}},
+	}
+}
+
+type profilerKey struct{}
+
+// WithProfiler returns a context that records the frames evaluated with it in p
+func WithProfiler(ctx context.Context, p *Profiler) context.Context {
+	return context.WithValue(ctx, profilerKey{}, p)
+}
+
+// Duration returns the time since the profiler was created
+func (p *Profiler) Duration() time.Duration {
+	return time.Since(p.start)
+}
+
+// profileFrame starts a frame for the path if the context has a profiler and returns the function
+// that ends it. The suffix is appended to the path to name the frame. Expressions without a
+// position in the source, such as the body of a file, are not frames.
+func profileFrame(ctx context.Context, path, suffix string, pos Position) func() {
+	if ctx == nil || pos.Line == 0 {
+		return func() {}
+	}
+	p, ok := ctx.Value(profilerKey{}).(*Profiler)
+	if !ok {
+		return func() {}
+	}
+	p.enter(frameName(path, suffix), pos)
+	return p.exit
+}
+
+// frameName returns the name of a frame at path
+func frameName(path, suffix string) string {
+	if path == "" {
+		return "<root>" + suffix
+	}
+	return path + suffix
+}
+
+func (p *Profiler) allocs() uint64 {
+	metrics.Read(p.metric)
+	if p.metric[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return p.metric[0].Value.Uint64()
+}
+
+func (p *Profiler) enter(name string, pos Position) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.stack = append(p.stack, &profileEntry{
+		frame: ProfileFrame{
+			Name: name,
+			Pos:  pos,
+		},
+		start:  time.Now(),
+		allocs: p.allocs(),
+	})
+}
+
+func (p *Profiler) exit() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(p.stack) == 0 {
+		return
+	}
+	entry := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+
+	wall := time.Since(entry.start)
+	allocs := p.allocs() - entry.allocs
+	if len(p.stack) > 0 {
+		parent := p.stack[len(p.stack)-1]
+		parent.childWall += wall
+		parent.childAllocs += allocs
+	}
+
+	var (
+		stack = make([]ProfileFrame, 0, len(p.stack)+1)
+		key   strings.Builder
+	)
+	for _, e := range append(p.stack, entry) {
+		stack = append(stack, e.frame)
+		key.WriteString(e.frame.Name)
+		key.WriteString("@")
+		key.WriteString(e.frame.Pos.String())
+		key.WriteString(";")
+	}
+
+	sample, ok := p.samples[key.String()]
+	if !ok {
+		sample = &ProfileSample{
+			Stack: stack,
+		}
+		p.samples[key.String()] = sample
+	}
+	sample.Wall += wall - entry.childWall
+	if allocs > entry.childAllocs {
+		sample.Allocs += int64(allocs - entry.childAllocs)
+	}
+	sample.Count++
+}
+
+// Samples returns the samples recorded so far, sorted by stack
+func (p *Profiler) Samples() (result []ProfileSample) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	keys := make([]string, 0, len(p.samples))
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		result = append(result, *p.samples[key])
+	}
+	return
+}
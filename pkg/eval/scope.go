@@ -14,6 +14,8 @@ type ScopeOption struct {
 	Call         bool
 	Path         string
 	Context      context.Context
+	// Tracer is reported the evaluation done in the scope
+	Tracer Tracer
 }
 
 func combine(opts []ScopeOption) (result ScopeOption) {
@@ -37,9 +39,9 @@ func combine(opts []ScopeOption) (result ScopeOption) {
 		if opt.Context != nil {
 			result.Context = opt.Context
 		}
-	}
-	if result.Context == nil {
-		result.Context = context.Background()
+		if opt.Tracer != nil {
+			result.Tracer = opt.Tracer
+		}
 	}
 	return
 }
@@ -101,6 +103,7 @@ func (e EmptyScope) AllowNewKeys() bool {
 
 type nested struct {
 	depth    int
+	maxDepth int
 	path     string
 	parent   Scope
 	lookup   ScopeLookuper
@@ -143,8 +146,8 @@ func (n nested) Context() context.Context {
 }
 
 func (n nested) Get(key string) (ret value.Value, ok bool, err error) {
-	if n.depth > MaxCallDepth {
-		return nil, false, fmt.Errorf("exceeded max scope depth %d > %d", n.depth, MaxCallDepth)
+	if n.depth > n.maxDepth {
+		return nil, false, fmt.Errorf("exceeded max scope depth %d > %d", n.depth, n.maxDepth)
 	}
 	if v, ok := n.keyCache[key]; ok {
 		return v, true, nil
@@ -185,12 +188,20 @@ func scopePush(n Scope, lookup ScopeLookuper, opts ...ScopeOption) Scope {
 		keyCache: make(map[string]value.Value),
 	}
 
-	ctx := n.Context()
-	depth, _ := ctx.Value(depthKey{}).(int)
+	depth, _ := n.Context().Value(depthKey{}).(int)
 	depth = depth + 1
 
+	ctx := o.Context
+	if ctx == nil {
+		ctx = n.Context()
+	}
+	if o.Tracer != nil {
+		ctx = WithTracer(ctx, o.Tracer)
+	}
+
 	newScope.opts.Context = context.WithValue(ctx, depthKey{}, depth)
 	newScope.depth = depth
+	newScope.maxDepth = maxDepth(ctx)
 	return newScope
 }
 
@@ -0,0 +1,158 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/acorn-io/aml/pkg/value"
+)
+
+// Tracer observes an evaluation. The methods are called synchronously while evaluating so they
+// should return quickly. Embed NopTracer to only implement some of the methods.
+type Tracer interface {
+	// CallEnter is called before a function is called
+	CallEnter(ctx context.Context, call TraceCall)
+	// CallExit is called after a function returns
+	CallExit(ctx context.Context, call TraceCall, result value.Value, err error)
+	// Field is called after the value of a field is evaluated, path is the dotted path of the field
+	Field(ctx context.Context, path string, pos Position, result value.Value, err error)
+	// SchemaMerge is called after a value is validated against a schema, such as the arguments of a
+	// function against the args of the function
+	SchemaMerge(ctx context.Context, pos Position, schema, v, result value.Value, err error)
+	// Debug is called with the formatted message of each call to debug(), even if debugging is not
+	// enabled
+	Debug(ctx context.Context, message string)
+	// Error is called once for each error raised while evaluating, at the innermost position it
+	// is seen
+	Error(ctx context.Context, err error)
+}
+
+// TraceCall is a function call. Name is the expression that was called, such as std.join, and Pos
+// is the position of the call.
+type TraceCall struct {
+	Name string
+	Pos  Position
+	Args []value.CallArgument
+}
+
+// NopTracer implements Tracer by doing nothing
+type NopTracer struct{}
+
+func (NopTracer) CallEnter(context.Context, TraceCall) {}
+
+func (NopTracer) CallExit(context.Context, TraceCall, value.Value, error) {}
+
+func (NopTracer) Field(context.Context, string, Position, value.Value, error) {}
+
+func (NopTracer) SchemaMerge(context.Context, Position, value.Value, value.Value, value.Value, error) {
+}
+
+func (NopTracer) Debug(context.Context, string) {}
+
+func (NopTracer) Error(context.Context, error) {}
+
+type tracerKey struct{}
+
+type tracing struct {
+	tracer   Tracer
+	lock     sync.Mutex
+	reported []error
+}
+
+// WithTracer returns a context that reports the evaluation done with it to t
+func WithTracer(ctx context.Context, t Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, &tracing{
+		tracer: t,
+	})
+}
+
+func getTracing(ctx context.Context) (*tracing, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	t, ok := ctx.Value(tracerKey{}).(*tracing)
+	return t, ok
+}
+
+// traceError reports err to the tracer unless it or an error it wraps has already been reported
+func traceError(ctx context.Context, err error) {
+	t, ok := getTracing(ctx)
+	if !ok || err == nil {
+		return
+	}
+
+	t.lock.Lock()
+	for _, reported := range t.reported {
+		if errors.Is(err, reported) {
+			t.lock.Unlock()
+			return
+		}
+	}
+	t.reported = append(t.reported, err)
+	t.lock.Unlock()
+
+	t.tracer.Error(ctx, err)
+}
+
+func traceField(ctx context.Context, path string, pos Position, result value.Value, err error) {
+	t, ok := getTracing(ctx)
+	if !ok {
+		return
+	}
+	traceError(ctx, err)
+	t.tracer.Field(ctx, path, pos, result, err)
+}
+
+func traceDebug(ctx context.Context, message string) {
+	if t, ok := getTracing(ctx); ok {
+		t.tracer.Debug(ctx, message)
+	}
+}
+
+// traceCall reports entering the call if the context has a tracer and returns the function that
+// reports the exit
+func traceCall(ctx context.Context, name string, pos Position, args []value.CallArgument) func(value.Value, error) {
+	t, ok := getTracing(ctx)
+	if !ok {
+		return func(value.Value, error) {}
+	}
+	call := TraceCall{
+		Name: name,
+		Pos:  pos,
+		Args: args,
+	}
+	t.tracer.CallEnter(ctx, call)
+	return func(result value.Value, err error) {
+		traceError(ctx, err)
+		t.tracer.CallExit(ctx, call, result, err)
+	}
+}
+
+// MergeSchema validates v against the schema and reports the merge to the tracer of the context
+func MergeSchema(ctx context.Context, pos Position, schema, v value.Value) (value.Value, error) {
+	result, err := value.Merge(schema, v)
+	if t, ok := getTracing(ctx); ok {
+		traceError(ctx, err)
+		t.tracer.SchemaMerge(ctx, pos, schema, v, result, err)
+	}
+	return result, err
+}
+
+// callName returns the name of the function called by the expression
+func callName(expr Expression) string {
+	switch e := expr.(type) {
+	case *Lookup:
+		return e.Key
+	case *Selector:
+		if key, ok := e.Key.(Value); ok {
+			if s, ok := key.Value.(value.String); ok {
+				return callName(e.Base) + "." + string(s)
+			}
+		}
+		return callName(e.Base) + "[]"
+	case *Parens:
+		return callName(e.Expr)
+	}
+	return "<func>"
+}
@@ -0,0 +1,117 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/acorn-io/aml/pkg/errors"
+	"github.com/acorn-io/aml/pkg/value"
+)
+
+// Warning is a problem found while evaluating that does not stop the evaluation, such as an
+// argument that is deprecated or that the file does not define
+type Warning struct {
+	Pos     Position
+	Path    string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.Message, w.Pos)
+}
+
+// Warnings collects the warnings of the evaluations done with a context from WithWarnings. Each
+// warning is only collected once.
+type Warnings struct {
+	// AsErrors fails the evaluation with an error at the first warning
+	AsErrors bool
+
+	lock   sync.Mutex
+	parent *Warnings
+	seen   map[Warning]bool
+	list   []Warning
+}
+
+func NewWarnings() *Warnings {
+	return &Warnings{}
+}
+
+type warningsKey struct{}
+
+// WithWarnings returns a context that collects the warnings of the evaluations done with it in
+// w. If the context already collects warnings they are also added to the existing collection.
+func WithWarnings(ctx context.Context, w *Warnings) context.Context {
+	if parent, ok := ctx.Value(warningsKey{}).(*Warnings); ok && !parent.has(w) {
+		w.parent = parent
+	}
+	return context.WithValue(ctx, warningsKey{}, w)
+}
+
+// List returns the warnings in the order they were found
+func (w *Warnings) List() []Warning {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return append([]Warning(nil), w.list...)
+}
+
+// add adds the warning to w and its parents, false is returned if w already has it
+func (w *Warnings) add(warning Warning) bool {
+	w.lock.Lock()
+	if w.seen == nil {
+		w.seen = map[Warning]bool{}
+	}
+	added := !w.seen[warning]
+	if added {
+		w.seen[warning] = true
+		w.list = append(w.list, warning)
+	}
+	w.lock.Unlock()
+
+	if w.parent != nil {
+		w.parent.add(warning)
+	}
+	return added
+}
+
+// has returns true if other is w or one of its parents
+func (w *Warnings) has(other *Warnings) bool {
+	for ; w != nil; w = w.parent {
+		if w == other {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Warnings) asErrors() bool {
+	for ; w != nil; w = w.parent {
+		if w.AsErrors {
+			return true
+		}
+	}
+	return false
+}
+
+// warn records a warning in the collection of the context and logs it to the log handler of the
+// context. If warnings are errors the warning is returned as an error instead.
+func warn(ctx context.Context, pos Position, path, format string, args ...any) error {
+	w, ok := ctx.Value(warningsKey{}).(*Warnings)
+	if !ok {
+		return nil
+	}
+
+	warning := Warning{
+		Pos:     pos,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	}
+	if w.asErrors() {
+		return errors.NewErrEval(value.Position(pos), fmt.Errorf("%s", warning.Message))
+	}
+	if w.add(warning) {
+		logAt(ctx, slog.LevelWarn, pos, path, warning.Message)
+	}
+	return nil
+}
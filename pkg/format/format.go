@@ -29,6 +29,23 @@ import (
 // An Option sets behavior of the formatter.
 type Option func(c *config)
 
+// UseSpaces specifies that tabs should be converted to spaces and sets the
+// default tab width.
+func UseSpaces(tabwidth int) Option {
+	return func(c *config) {
+		c.UseSpaces = true
+		c.Tabwidth = tabwidth
+	}
+}
+
+// TabIndent specifies whether to use tabs for indentation independent of
+// UseSpaces.
+func TabIndent(indent bool) Option {
+	return func(c *config) {
+		c.TabIndent = indent
+	}
+}
+
 func Node(node ast.Node, opt ...Option) ([]byte, error) {
 	cfg := newConfig(opt)
 	return cfg.fprint(node)
@@ -246,8 +263,10 @@ func (f *formatter) printComment(cg *ast.CommentGroup) {
 	f.Print(cg)
 
 	printBlank := false
-	if cg.Doc && len(f.output) > 0 {
-		f.Print(newline)
+	if cg.Doc {
+		if len(f.output) > 0 {
+			f.Print(newline)
+		}
 		printBlank = true
 	}
 	for _, c := range cg.List {
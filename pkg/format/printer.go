@@ -86,7 +86,7 @@ func (p *printer) Print(v interface{}) {
 	case token.Token:
 		s := x.String()
 		before, after := mayCombine(p.lastTok, x)
-		if before && !p.spaceBefore {
+		if before && !p.spaceBefore && len(p.output) > 0 {
 			// the previous and the current token must be
 			// separated by a blank otherwise they combine
 			// into a different incorrect token sequence
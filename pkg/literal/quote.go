@@ -0,0 +1,34 @@
+package literal
+
+import (
+	"strings"
+	"unicode"
+)
+
+// QuoteMulti returns s as a triple quoted multiline string literal where each line is indented by
+// n tabs. Blank lines are indented too so that the indentation can be stripped reliably when the
+// literal is unquoted. False is returned if s does not contain a newline or it can not be
+// represented as a multiline literal.
+func QuoteMulti(s string, n int) (string, bool) {
+	if !strings.Contains(s, "\n") || strings.Contains(s, `"""`) {
+		return "", false
+	}
+	for _, r := range s {
+		if r != '\n' && r != '\t' && unicode.IsControl(r) {
+			return "", false
+		}
+	}
+
+	indent := tabs(n)
+	buf := strings.Builder{}
+	buf.WriteString(`"""`)
+	for _, line := range strings.Split(s, "\n") {
+		buf.WriteString("\n")
+		buf.WriteString(indent)
+		buf.WriteString(strings.ReplaceAll(line, `\`, `\\`))
+	}
+	buf.WriteString("\n")
+	buf.WriteString(indent)
+	buf.WriteString(`"""`)
+	return buf.String(), true
+}
@@ -0,0 +1,39 @@
+package literal
+
+import (
+	"testing"
+)
+
+func TestQuoteMulti(t *testing.T) {
+	testCases := []struct {
+		in  string
+		out string
+		ok  bool
+	}{{
+		in:  "foo\nbar",
+		out: "\"\"\"\n\t\tfoo\n\t\tbar\n\t\t\"\"\"",
+		ok:  true,
+	}, {
+		in:  "foo\n\nbar\n",
+		out: "\"\"\"\n\t\tfoo\n\t\t\n\t\tbar\n\t\t\n\t\t\"\"\"",
+		ok:  true,
+	}, {
+		in:  "say \"\\(hi)\"\n",
+		out: "\"\"\"\n\t\tsay \"\\\\(hi)\"\n\t\t\n\t\t\"\"\"",
+		ok:  true,
+	}, {
+		in: "foo",
+	}, {
+		in: "foo\n\"\"\"",
+	}, {
+		in: "foo\r\nbar",
+	}}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			got, ok := QuoteMulti(tc.in, 2)
+			if got != tc.out || ok != tc.ok {
+				t.Errorf("got %s, %v; want %s, %v", got, ok, tc.out, tc.ok)
+			}
+		})
+	}
+}
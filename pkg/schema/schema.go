@@ -5,6 +5,9 @@ package schema
 type File struct {
 	Args         Object
 	ProfileNames Names
+	// Profiles describes the values each profile sets, the default of each field is the value
+	// the profile assigns to the arg of the same name
+	Profiles Object
 }
 
 type Names []Name
@@ -70,6 +73,19 @@ type FieldType struct {
 	Constraint []Constraint `json:"constraint,omitempty"`
 	Default    any          `json:"default,omitempty"`
 	Alternate  *FieldType   `json:"alternate,omitempty"`
+	// Sensitive is set if the value is a secret that is redacted in output
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// DefaultValue returns the default of the type or, if it has none, of the first alternate that
+// has one. Nil is returned if there is no default.
+func (f FieldType) DefaultValue() any {
+	for t := &f; t != nil; t = t.Alternate {
+		if t.Default != nil {
+			return t.Default
+		}
+	}
+	return nil
 }
 
 func mergeAlternate(left, right *FieldType) *FieldType {
@@ -93,6 +109,7 @@ func (f FieldType) Merge(right FieldType) (result FieldType) {
 		result.Object = right.Object
 	}
 	result.Constraint = append(f.Constraint, right.Constraint...)
+	result.Sensitive = f.Sensitive || right.Sensitive
 	if right.Default != nil {
 		f.Default = right.Default
 	}
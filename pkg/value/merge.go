@@ -17,6 +17,9 @@ func mergeNative(left, right Value) (Value, error) {
 	if !b {
 		return nil, fmt.Errorf("can not override value %s with %s", left, right)
 	}
+	if IsSecret(left) {
+		return NewSecret(right), nil
+	}
 	return right, nil
 }
 
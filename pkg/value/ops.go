@@ -260,6 +260,22 @@ func BinaryOperation(op Operator, left, right Value) (Value, error) {
 		return undef, nil
 	}
 
+	// operations on a secret left operand taint the result in the Secret methods
+	if IsSecret(right) && !IsSecret(left) {
+		switch op {
+		case AddOp, SubOp, MulOp, DivOp:
+			result, err := binaryOperation(op, left, right)
+			if err != nil {
+				return nil, err
+			}
+			return NewSecret(result), nil
+		}
+	}
+
+	return binaryOperation(op, left, right)
+}
+
+func binaryOperation(op Operator, left, right Value) (Value, error) {
 	switch op {
 	case AddOp:
 		return Add(left, right)
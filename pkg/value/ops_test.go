@@ -102,3 +102,37 @@ func TestBinary(t *testing.T) {
 		})
 	}
 }
+
+func TestSecret(t *testing.T) {
+	secret := NewSecret(NewValue("hunter2"))
+
+	v, err := BinaryOperation(AddOp, NewValue("pass:"), secret)
+	require.NoError(t, err)
+	assert.True(t, IsSecret(v))
+	autogold.Expect("(redacted)").Equal(t, fmt.Sprint(v))
+
+	s, err := ToString(v)
+	require.NoError(t, err)
+	autogold.Expect("pass:hunter2").Equal(t, s)
+
+	v, err = BinaryOperation(EqOp, secret, NewValue("hunter2"))
+	require.NoError(t, err)
+	assert.False(t, IsSecret(v))
+	autogold.Expect(Boolean(true)).Equal(t, v)
+
+	obj := NewValue(map[string]any{
+		"user":     "admin",
+		"password": secret,
+		"tokens":   []any{NewSecret(NewValue(1)), 2},
+	})
+	assert.True(t, ContainsSecret(obj))
+	autogold.Expect(`{"password":"(redacted)","tokens":["(redacted)",2],"user":"admin"}`).Equal(t, fmt.Sprint(obj))
+
+	nv, _, err := NativeValue(Redact(obj))
+	require.NoError(t, err)
+	autogold.Expect(map[string]interface{}{
+		"password": "(redacted)",
+		"tokens":   []interface{}{"(redacted)", Number("2")},
+		"user":     "admin",
+	}).Equal(t, nv)
+}
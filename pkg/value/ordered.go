@@ -0,0 +1,231 @@
+package value
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OrderedMap is the native representation of an object that keeps the keys in the order
+// they were defined. It marshals to JSON and YAML in that order.
+type OrderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func (m *OrderedMap) Keys() []string {
+	return m.keys
+}
+
+func (m *OrderedMap) Len() int {
+	return len(m.keys)
+}
+
+func (m *OrderedMap) Get(key string) (any, bool) {
+	v, ok := m.values[key]
+	return v, ok
+}
+
+// Set sets the value of key. A new key is added to the end, an existing key keeps its position.
+func (m *OrderedMap) Set(key string, v any) {
+	if m.values == nil {
+		m.values = map[string]any{}
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString("{")
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		keyData, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		valueData, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keyData)
+		buf.WriteString(":")
+		buf.Write(valueData)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a JSON object keeping the key order. Nested objects are decoded as
+// *OrderedMap and numbers as float64, the same as encoding/json does for interface values.
+func (m *OrderedMap) UnmarshalJSON(data []byte) error {
+	v, err := decodeOrderedJSON(json.NewDecoder(bytes.NewReader(data)))
+	if err != nil {
+		return err
+	}
+	obj, ok := v.(*OrderedMap)
+	if !ok {
+		return fmt.Errorf("can not unmarshal %T into an ordered map", v)
+	}
+	*m = *obj
+	return nil
+}
+
+func decodeOrderedJSON(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		result := &OrderedMap{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeOrderedJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			result.Set(key.(string), v)
+		}
+		_, err := dec.Token()
+		return result, err
+	case json.Delim('['):
+		result := []any{}
+		for dec.More() {
+			v, err := decodeOrderedJSON(dec)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, v)
+		}
+		_, err := dec.Token()
+		return result, err
+	}
+
+	return tok, nil
+}
+
+func (m *OrderedMap) MarshalYAML() (any, error) {
+	return OrderedYAMLNode(m)
+}
+
+// OrderedYAMLNode converts a value returned by OrderedNativeValue to a YAML node, keeping the
+// order of the keys and writing each Number as an !!int or !!float
+func OrderedYAMLNode(v any) (*yaml.Node, error) {
+	switch v := v.(type) {
+	case *OrderedMap:
+		result := &yaml.Node{
+			Kind: yaml.MappingNode,
+			Tag:  "!!map",
+		}
+		for _, key := range v.keys {
+			child, err := OrderedYAMLNode(v.values[key])
+			if err != nil {
+				return nil, err
+			}
+			result.Content = append(result.Content, &yaml.Node{
+				Kind:  yaml.ScalarNode,
+				Tag:   "!!str",
+				Value: key,
+			}, child)
+		}
+		return result, nil
+	case []any:
+		result := &yaml.Node{
+			Kind: yaml.SequenceNode,
+			Tag:  "!!seq",
+		}
+		for _, item := range v {
+			child, err := OrderedYAMLNode(item)
+			if err != nil {
+				return nil, err
+			}
+			result.Content = append(result.Content, child)
+		}
+		return result, nil
+	case Number:
+		return numberYAMLNode(v)
+	}
+
+	result := &yaml.Node{}
+	return result, result.Encode(v)
+}
+
+var (
+	yamlInt   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlFloat = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+)
+
+// numberYAMLNode returns the node of a number. Integers are written with their digits, so that
+// integers of any size are kept exactly. Other numbers keep their text if it reads as a float in
+// YAML.
+func numberYAMLNode(n Number) (*yaml.Node, error) {
+	data, err := n.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	text := string(data)
+	if yamlInt.MatchString(text) {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: text}, nil
+	} else if yamlFloat.MatchString(text) {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: text}, nil
+	}
+
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, err
+	}
+	text = strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(text, ".e") {
+		text += ".0"
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: text}, nil
+}
+
+// OrderedNativeValue is the same as NativeValue except that objects are returned as
+// *OrderedMap so that the key order is preserved.
+func OrderedNativeValue(v Value) (any, bool, error) {
+	switch v := v.(type) {
+	case *Object:
+		result := &OrderedMap{}
+		for _, entry := range v.Entries {
+			nv, ok, err := OrderedNativeValue(entry.Value)
+			if err != nil {
+				return nil, false, err
+			}
+			if !ok {
+				continue
+			}
+			result.Set(entry.Key, nv)
+		}
+		return result, true, nil
+	case Array:
+		result := make([]any, 0, len(v))
+		for _, item := range v {
+			nv, ok, err := OrderedNativeValue(item)
+			if err != nil {
+				return nil, false, err
+			}
+			if !ok {
+				continue
+			}
+			result = append(result, nv)
+		}
+		return result, true, nil
+	}
+	return NativeValue(v)
+}
@@ -0,0 +1,265 @@
+package value
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Redacted replaces the value of secrets in errors, debug output and rendered output
+const Redacted = "(redacted)"
+
+// RevealSecrets disables the redaction of secrets when they are formatted
+var RevealSecrets = false
+
+// Secret is a sensitive string, number or bool. A secret behaves like the value it wraps, except
+// that it is redacted when formatted. The result of an arithmetic operation, a string
+// interpolation or a native function that uses a secret is also a secret.
+type Secret struct {
+	Value Value
+}
+
+// NewSecret marks the value as a secret. The items of arrays and the values of objects are marked
+// individually, values that are not a string, number or bool are returned unchanged.
+func NewSecret(v Value) Value {
+	if v == nil {
+		return nil
+	}
+	switch v.Kind() {
+	case StringKind, NumberKind, BoolKind:
+		if IsSecret(v) {
+			return v
+		}
+		return Secret{Value: v}
+	case ArrayKind:
+		items, err := ToValueArray(v)
+		if err != nil {
+			return v
+		}
+		result := make(Array, 0, len(items))
+		for _, item := range items {
+			result = append(result, NewSecret(item))
+		}
+		return result
+	case ObjectKind:
+		entries, err := Entries(v)
+		if err != nil {
+			return v
+		}
+		result := &Object{}
+		for _, entry := range entries {
+			result.Entries = append(result.Entries, Entry{
+				Key:   entry.Key,
+				Value: NewSecret(entry.Value),
+			})
+		}
+		return result
+	}
+	return v
+}
+
+// IsSecret returns true if v is a secret
+func IsSecret(v Value) bool {
+	_, ok := v.(Secret)
+	return ok
+}
+
+// ContainsSecret returns true if v is a secret or an array or object that contains a secret
+func ContainsSecret(v Value) bool {
+	switch {
+	case v == nil:
+		return false
+	case IsSecret(v):
+		return true
+	case v.Kind() == ArrayKind:
+		items, err := ToValueArray(v)
+		if err != nil {
+			return false
+		}
+		for _, item := range items {
+			if ContainsSecret(item) {
+				return true
+			}
+		}
+	case v.Kind() == ObjectKind:
+		entries, err := Entries(v)
+		if err != nil {
+			return false
+		}
+		for _, entry := range entries {
+			if ContainsSecret(entry.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Redact returns v with every secret replaced by the string (redacted). If v contains no secrets
+// it is returned unchanged.
+func Redact(v Value) Value {
+	if !ContainsSecret(v) {
+		return v
+	}
+	switch {
+	case IsSecret(v):
+		return NewValue(Redacted)
+	case v.Kind() == ArrayKind:
+		items, _ := ToValueArray(v)
+		result := make(Array, 0, len(items))
+		for _, item := range items {
+			result = append(result, Redact(item))
+		}
+		return result
+	default:
+		entries, _ := Entries(v)
+		result := &Object{}
+		for _, entry := range entries {
+			result.Entries = append(result.Entries, Entry{
+				Key:   entry.Key,
+				Value: Redact(entry.Value),
+			})
+		}
+		return result
+	}
+}
+
+// UnwrapSecret returns the value wrapped by a secret, other values are returned unchanged
+func UnwrapSecret(v Value) Value {
+	if s, ok := v.(Secret); ok {
+		return s.Value
+	}
+	return v
+}
+
+func (s Secret) Kind() Kind {
+	return s.Value.Kind()
+}
+
+func (s Secret) NativeValue() (any, bool, error) {
+	return NativeValue(s.Value)
+}
+
+func (s Secret) String() string {
+	if RevealSecrets {
+		return fmt.Sprint(s.Value)
+	}
+	return Redacted
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	if RevealSecrets {
+		return json.Marshal(s.Value)
+	}
+	return json.Marshal(Redacted)
+}
+
+// redactErr removes the value of the secret from the message of an error returned by an operation
+// on the wrapped value.
+func (s Secret) redactErr(err error) error {
+	if err == nil || RevealSecrets {
+		return err
+	}
+	nv, ok, nvErr := NativeValue(s.Value)
+	if nvErr != nil || !ok {
+		return err
+	}
+	str := fmt.Sprint(nv)
+	if str == "" || !strings.Contains(err.Error(), str) {
+		return err
+	}
+	return errors.New(strings.ReplaceAll(err.Error(), str, Redacted))
+}
+
+// secretOp applies an arithmetic operation to the wrapped value, the result is a secret
+func (s Secret) secretOp(op func(left, right Value) (Value, error), right Value) (Value, error) {
+	v, err := op(s.Value, UnwrapSecret(right))
+	if err != nil {
+		return nil, s.redactErr(err)
+	}
+	return NewSecret(v), nil
+}
+
+// compareOp applies a comparison to the wrapped value, the result is not a secret
+func (s Secret) compareOp(op func(left, right Value) (Value, error), right Value) (Value, error) {
+	v, err := op(s.Value, UnwrapSecret(right))
+	return v, s.redactErr(err)
+}
+
+func (s Secret) Add(right Value) (Value, error) {
+	return s.secretOp(Add, right)
+}
+
+func (s Secret) Sub(right Value) (Value, error) {
+	return s.secretOp(Sub, right)
+}
+
+func (s Secret) Mul(right Value) (Value, error) {
+	return s.secretOp(Mul, right)
+}
+
+func (s Secret) Div(right Value) (Value, error) {
+	return s.secretOp(Div, right)
+}
+
+func (s Secret) And(right Value) (Value, error) {
+	return s.compareOp(And, right)
+}
+
+func (s Secret) Or(right Value) (Value, error) {
+	return s.compareOp(Or, right)
+}
+
+func (s Secret) Lt(right Value) (Value, error) {
+	return s.compareOp(Lt, right)
+}
+
+func (s Secret) Le(right Value) (Value, error) {
+	return s.compareOp(Le, right)
+}
+
+func (s Secret) Gt(right Value) (Value, error) {
+	return s.compareOp(Gt, right)
+}
+
+func (s Secret) Ge(right Value) (Value, error) {
+	return s.compareOp(Ge, right)
+}
+
+func (s Secret) Eq(right Value) (Value, error) {
+	return s.compareOp(Eq, right)
+}
+
+func (s Secret) Neq(right Value) (Value, error) {
+	return s.compareOp(Neq, right)
+}
+
+func (s Secret) Mat(right Value) (Value, error) {
+	return s.compareOp(Mat, right)
+}
+
+func (s Secret) Nmat(right Value) (Value, error) {
+	return s.compareOp(Nmat, right)
+}
+
+func (s Secret) Match(right Value) (bool, error) {
+	m, ok := s.Value.(interface {
+		Match(right Value) (bool, error)
+	})
+	if !ok {
+		return false, fmt.Errorf("value kind %s does not support match operation", s.Kind())
+	}
+	b, err := m.Match(UnwrapSecret(right))
+	return b, s.redactErr(err)
+}
+
+func (s Secret) ToInt() (int64, error) {
+	i, err := ToInt(s.Value)
+	return i, s.redactErr(err)
+}
+
+func (s Secret) ToFloat() (float64, error) {
+	f, err := ToFloat(s.Value)
+	return f, s.redactErr(err)
+}
@@ -41,6 +41,8 @@ type TypeSchema struct {
 	Constraints  []Checker
 	Alternate    *TypeSchema
 	DefaultValue Value
+	// Sensitive marks the values that match the schema as secrets
+	Sensitive bool
 }
 
 func NewMatchTypeWithDefault(v Value) Value {
@@ -109,6 +111,7 @@ func checkerToConstraint(checker Checker) (result schema.Constraint, _ bool, _ e
 
 func typeSchemaToFieldType(ctx SchemaContext, n *TypeSchema) (result schema.FieldType, ok bool, err error) {
 	result.Kind = schema.Kind(n.KindValue)
+	result.Sensitive = n.Sensitive
 
 	if n.DefaultValue != nil {
 		def, ok, err := NativeValue(n.DefaultValue)
@@ -116,6 +119,9 @@ func typeSchemaToFieldType(ctx SchemaContext, n *TypeSchema) (result schema.Fiel
 			return result, ok, err
 		}
 		result.Default = def
+		if (n.Sensitive || ContainsSecret(n.DefaultValue)) && !RevealSecrets {
+			result.Default = Redacted
+		}
 	}
 
 	for _, checker := range n.Constraints {
@@ -125,12 +131,21 @@ func typeSchemaToFieldType(ctx SchemaContext, n *TypeSchema) (result schema.Fiel
 		} else if !ok {
 			continue
 		}
+		if n.Sensitive && constraint.Right != nil && !RevealSecrets {
+			constraint.Right = Redacted
+		}
 
 		result.Constraint = append(result.Constraint, constraint)
 	}
 
 	if n.Alternate != nil {
-		alt, ok, err := typeSchemaToFieldType(ctx, n.Alternate)
+		alternate := n.Alternate
+		if n.Sensitive && !alternate.Sensitive {
+			cp := *alternate
+			cp.Sensitive = true
+			alternate = &cp
+		}
+		alt, ok, err := typeSchemaToFieldType(ctx, alternate)
 		if err != nil || !ok {
 			return result, ok, err
 		}
@@ -288,6 +303,7 @@ func (n *TypeSchema) And(right Value) (Value, error) {
 	cp := *n
 	cp.Alternate = mergeAlternate(&cp, rightSchema.Alternate)
 	cp.Constraints = append(cp.Constraints, rightSchema.Constraints...)
+	cp.Sensitive = n.Sensitive || rightSchema.Sensitive
 	if cp.DefaultValue == nil {
 		cp.DefaultValue = rightSchema.DefaultValue
 	} else if rightSchema.DefaultValue != nil {
@@ -347,7 +363,9 @@ func (n *TypeSchema) Or(right Value) (Value, error) {
 		rightSchema = NewDefault(right).(*TypeSchema)
 	}
 
-	return mergeAlternate(n, rightSchema), nil
+	result := mergeAlternate(n, rightSchema)
+	result.Sensitive = n.Sensitive || rightSchema.Sensitive
+	return result, nil
 }
 
 func (n *TypeSchema) renderDefaultObject() (_ Value, _ bool, retErr error) {
@@ -361,6 +379,17 @@ func (n *TypeSchema) renderDefaultArray() (_ Value, _ bool, retErr error) {
 }
 
 func (n *TypeSchema) Default() (Value, bool, error) {
+	if n.Sensitive {
+		v, ok, err := n.defaultValue()
+		if err != nil || !ok {
+			return v, ok, err
+		}
+		return NewSecret(v), true, nil
+	}
+	return n.defaultValue()
+}
+
+func (n *TypeSchema) defaultValue() (Value, bool, error) {
 	if n.DefaultValue != nil {
 		return n.DefaultValue, true, nil
 	}
@@ -511,6 +540,10 @@ func (n *TypeSchema) Merge(right Value) (Value, error) {
 	if right.Kind() == SchemaKind {
 		return And(n, right)
 	}
+	if n.Sensitive {
+		// marked before checking so that the value is redacted in errors
+		right = NewSecret(right)
+	}
 	return checkType(n, right)
 }
 